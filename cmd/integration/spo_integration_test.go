@@ -6,7 +6,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
-	"github.com/spolabs/wallet-api/src/api/mobile"
+	"github.com/MDLlife/wallet-api/src/api/mobile"
 )
 
 func TestAllFunction(t *testing.T) {
@@ -18,7 +18,7 @@ func TestAllFunction(t *testing.T) {
 	newseed := "greate test"
 
 	coinTypes := mobile.GetSupportedCoin()
-	assert.Equal(t, coinTypes, "skycoin,spo,suncoin,shellcoin,mzcoin,aynrandcoin")
+	assert.Equal(t, coinTypes, "skycoin,samos,spo,suncoin,shellcoin,mzcoin,aynrandcoin,mdl")
 	err := mobile.Init(walletDir, password)
 	assert.NoError(t, err)
 	err = mobile.RegisterNewCoin("spo", "182.92.180.92:8620")
@@ -26,7 +26,7 @@ func TestAllFunction(t *testing.T) {
 	if !mobile.IsExist(wlt) {
 		fmt.Printf("wallet not exists\n")
 		lable := "lable"
-		wlt, err = mobile.NewWallet(wltType, lable, newseed, password)
+		wlt, err = mobile.NewBrainWallet(wltType, lable, newseed, password)
 		assert.NoError(t, err)
 		assert.Equal(t, wlt, wltType+"_"+wltKey)
 		address, err := mobile.NewAddress(wlt, 2, password)
@@ -3,7 +3,7 @@ package main
 import (
 	"fmt"
 
-	"github.com/spolabs/wallet-api/src/api/mobile"
+	"github.com/MDLlife/wallet-api/src/api/mobile"
 )
 
 func main() {
@@ -31,7 +31,7 @@ func main() {
 	if !mobile.IsExist(wlt) {
 		fmt.Printf("wallet not exists\n")
 		lable := "lable"
-		wlt, err = mobile.NewWallet(wltType, lable, newseed, password)
+		wlt, err = mobile.NewBrainWallet(wltType, lable, newseed, password)
 		if err != nil {
 			fmt.Printf("---new wallet err--%v\n", err)
 			if err.Error() != "_"+wlt+" already exist" {
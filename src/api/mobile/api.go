@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/skycoin/skycoin/src/util/droplet"
 	skywallet "github.com/skycoin/skycoin/src/wallet"
@@ -17,6 +18,9 @@ import (
 	"github.com/MDLlife/wallet-api/src/coin/spo"
 	"github.com/MDLlife/wallet-api/src/coin/mdl"
 	"github.com/MDLlife/wallet-api/src/coin/suncoin"
+	"github.com/MDLlife/wallet-api/src/paper"
+	"github.com/MDLlife/wallet-api/src/util/encrypt"
+	"github.com/MDLlife/wallet-api/src/util/secure"
 	"github.com/MDLlife/wallet-api/src/wallet"
 )
 
@@ -24,22 +28,89 @@ import (
 
 var coinMap map[string]Coiner
 
-// Init initialize wallet dir and coin manager. must gave password
+// Init initialize wallet dir and coin manager, bad wallet files are skipped instead of
+// failing the init. passwd is kept for compatibility and not used, each wallet keeps its own
+// password.
+//
+// Deprecated: Init drops the load report, the skipped and corrupt wallet files are only
+// returned by InitWithReport, callers must switch to InitWithReport to see them.
 func Init(walletDir, passwd string) error {
+	_, err := InitWithReport(walletDir)
+	return err
+}
+
+// InitWithReport initialize wallet dir and coin manager, returns the load report in json.
+func InitWithReport(walletDir string) (string, error) {
 	wallet.InitDir(walletDir)
-	if err := LoadWallet(passwd); err != nil {
-		return err
+	report, err := LoadWalletWithReport()
+	if err != nil {
+		return "", err
 	}
 	coinMap = make(map[string]Coiner)
-	return nil
+	return report, nil
 }
 
-// LoadWallet Load wallet already exists
+// LoadWallet Load wallet already exists, passwd is kept for compatibility and not used,
+// each wallet keeps its own password.
+//
+// Deprecated: LoadWallet drops the load report, callers must switch to LoadWalletWithReport
+// to see the skipped and corrupt wallet files.
 func LoadWallet(passwd string) error {
-	if len(passwd) == 0 {
-		return errors.New("password cannot empty")
+	_, err := LoadWalletWithReport()
+	return err
+}
+
+// LoadWalletWithReport Load wallet already exists, returns the report of loaded, skipped,
+// corrupt and recovered wallet files in json.
+func LoadWalletWithReport() (string, error) {
+	report, err := wallet.LoadWallet()
+	if err != nil {
+		return "", err
+	}
+	d, err := json.Marshal(report)
+	if err != nil {
+		return "", err
 	}
-	return wallet.LoadWallet(passwd)
+	return string(d), nil
+}
+
+// SetScryptParams set scrypt as the kdf of new wallets with the work factor,
+// n must be power of 2. Existing wallets keep their own parameters.
+func SetScryptParams(n, r, p int) error {
+	return wallet.SetEncryptOptions(encrypt.Options{
+		Cipher: encrypt.CipherScryptChacha20poly1305,
+		N:      n,
+		R:      r,
+		P:      p,
+	})
+}
+
+// SetArgon2idParams set argon2id as the kdf of new wallets, memory is in KiB.
+func SetArgon2idParams(time, memory, threads int) error {
+	if time <= 0 || memory <= 0 || threads <= 0 || threads > 255 {
+		return errors.New("invalid argon2id params")
+	}
+	return wallet.SetEncryptOptions(encrypt.Options{
+		Cipher:  encrypt.CipherArgon2idChacha20poly1305,
+		Time:    uint32(time),
+		Memory:  uint32(memory),
+		Threads: uint8(threads),
+	})
+}
+
+// SetWalletCipher re-encrypts the wallet with the cipher, can be `scrypt-chacha20poly1305`
+// or `argon2id-chacha20poly1305`, the default parameters of the cipher are used.
+func SetWalletCipher(walletID, cipher, passwd string) error {
+	return wallet.SetCipher(walletID, passwd, encrypt.Options{Cipher: cipher})
+}
+
+// SetRewrapCipher wallets encrypted by other cipher are re-encrypted with the cipher
+// on their next successful unlock, empty cipher disables the re-wrap.
+func SetRewrapCipher(cipher string) error {
+	if cipher == "" {
+		return wallet.SetRewrapOptions(nil)
+	}
+	return wallet.SetRewrapOptions(&encrypt.Options{Cipher: cipher})
 }
 
 // RegisterNewCoin register a new coin to wallet
@@ -59,7 +130,8 @@ func GetSupportedCoin() string {
 	return strings.Join(coinTypes, ",")
 }
 
-// NewWallet create a new wallet base on the wallet type and seed
+// NewWallet create a new wallet base on the wallet type and seed,
+// the seed must be bip39 mnemonic, a random one is generated if seed is empty.
 func NewWallet(coinType, lable, seed, passwd string) (string, error) {
 	if len(passwd) == 0 {
 		return "", errors.New("password cannot empty")
@@ -71,20 +143,260 @@ func NewWallet(coinType, lable, seed, passwd string) (string, error) {
 	return wlt.GetID(), nil
 }
 
+// NewWalletWithPassphrase create a new wallet base on bip39 mnemonic and passphrase,
+// restoring the wallet needs both of them.
+func NewWalletWithPassphrase(coinType, lable, seed, passphrase, passwd string) (string, error) {
+	if len(passwd) == 0 {
+		return "", errors.New("password cannot empty")
+	}
+	wlt, err := wallet.NewWithPassphrase(coinType, lable, seed, passphrase, passwd)
+	if err != nil {
+		return "", err
+	}
+	return wlt.GetID(), nil
+}
+
+// RestoreWallet restore wallet of bip39 mnemonic and passphrase, the addresses are
+// discovered from the coin node until gap consecutive addresses are unused,
+// gap <= 0 is the default 20.
+func RestoreWallet(coinType, lable, seed, passphrase, passwd string, gap int) (string, error) {
+	if len(passwd) == 0 {
+		return "", errors.New("password cannot empty")
+	}
+	cn, ok := coinMap[coinType]
+	if !ok {
+		return "", fmt.Errorf("%s is not supported", coinType)
+	}
+	wlt, err := wallet.Restore(coinType, lable, seed, passphrase, passwd, gap, cn.UsedAddresses)
+	if err != nil {
+		return "", err
+	}
+	return wlt.GetID(), nil
+}
+
+// NewBrainWallet create a new wallet base on arbitrary seed, the seed is not validated,
+// so the user must opt in explicitly.
+func NewBrainWallet(coinType, lable, seed, passwd string) (string, error) {
+	if len(passwd) == 0 {
+		return "", errors.New("password cannot empty")
+	}
+	wlt, err := wallet.NewBrainWallet(coinType, lable, seed, passwd)
+	if err != nil {
+		return "", err
+	}
+	return wlt.GetID(), nil
+}
+
+// ValidateSeed checks the seed is bip39 mnemonic, the error tells which word
+// is invalid or that the checksum failed.
+func ValidateSeed(seed string) error {
+	return wallet.ValidateMnemonic(seed)
+}
+
+// NewMultiCoinWallet create linked wallets of the coin types (format "c1,c2,c3") from one bip39
+// mnemonic, empty coinTypes means every supported coin, a random mnemonic is generated if seed is empty.
+// Removing any wallet of the group removes all of them, returns
+// {"group":"group_24argCsVuBMYEBr6","wallets":["skycoin_24argCsVuBMYEBr6","spo_24argCsVuBMYEBr6"]}
+func NewMultiCoinWallet(seed, passwd, coinTypes string) (string, error) {
+	if len(passwd) == 0 {
+		return "", errors.New("password cannot empty")
+	}
+	var tps []string
+	if coinTypes != "" {
+		tps = strings.Split(coinTypes, ",")
+	}
+	group, wlts, err := wallet.NewMultiCoinWallet(seed, passwd, tps)
+	if err != nil {
+		return "", err
+	}
+	ids := make([]string, len(wlts))
+	for i, wlt := range wlts {
+		ids[i] = wlt.GetID()
+	}
+	return groupJSON(group, ids)
+}
+
+// GetGroupWallets return the wallets of group, returns the same json as NewMultiCoinWallet.
+func GetGroupWallets(group string) (string, error) {
+	return groupJSON(group, wallet.GetGroup(group))
+}
+
+func groupJSON(group string, ids []string) (string, error) {
+	var res = struct {
+		Group   string   `json:"group"`
+		Wallets []string `json:"wallets"`
+	}{
+		Group:   group,
+		Wallets: ids,
+	}
+	d, err := json.Marshal(res)
+	if err != nil {
+		return "", err
+	}
+	return string(d), nil
+}
+
+// NewCollectionWallet create a wallet without seed, which holds the keys imported by ImportKey.
+func NewCollectionWallet(coinType, lable, passwd string) (string, error) {
+	if len(passwd) == 0 {
+		return "", errors.New("password cannot empty")
+	}
+	wlt, err := wallet.NewCollection(coinType, lable, passwd)
+	if err != nil {
+		return "", err
+	}
+	return wlt.GetID(), nil
+}
+
+// ImportKey import the seckey in hex into collection wallet, returns the address of the key,
+// passwd can be empty if the wallet is unlocked.
+func ImportKey(walletID, seckey, passwd string) (string, error) {
+	if len(passwd) == 0 && !wallet.IsUnlocked(walletID) {
+		return "", errors.New("password cannot empty")
+	}
+	return wallet.ImportKey(walletID, seckey, passwd)
+}
+
+// NewWatchWallet create a watch-only wallet of the addresses or pubkeys (format "k1,k2,k3"),
+// the wallet has no password, Send and other signing apis fail on it.
+func NewWatchWallet(coinType, lable, keys string) (string, error) {
+	wlt, err := wallet.NewWatchWallet(coinType, lable, strings.Split(keys, ","))
+	if err != nil {
+		return "", err
+	}
+	return wlt.GetID(), nil
+}
+
+// ListWallets return the loaded wallets, group is omitted if the wallet isn't in a group, returns
+// {"wallets":[{"id":"spo_24argCsVuBMYEBr6","type":"spo","lable":"spo","group":"group_24argCsVuBMYEBr6","tm":"1532312341","address_count":2,"version":"0.5","wallet_type":"deterministic","verified":true}]}
+func ListWallets() (string, error) {
+	var res = struct {
+		Wallets []wallet.WalletInfo `json:"wallets"`
+	}{
+		Wallets: wallet.ListWallets(),
+	}
+	d, err := json.Marshal(res)
+	if err != nil {
+		return "", err
+	}
+	return string(d), nil
+}
+
+// SetLabel change the lable of wallet without password.
+func SetLabel(walletID, label string) error {
+	return wallet.SetLable(walletID, label)
+}
+
+// ExportBackup export all wallets with lables and address metadata into one archive encrypted
+// by backupPasswd, passwd is the password of the wallets, empty passwd exports the unlocked
+// wallets by their session. The archive is used to move to
+// new phone, the wallets can't be decrypted are skipped, returns {"backup":"...","skipped":[]}
+func ExportBackup(passwd, backupPasswd string) (string, error) {
+	report, err := wallet.ExportBackup(passwd, backupPasswd)
+	if err != nil {
+		return "", err
+	}
+	d, err := json.Marshal(report)
+	if err != nil {
+		return "", err
+	}
+	return string(d), nil
+}
+
+// ImportBackup import the wallets of archive exported by ExportBackup, the imported wallets are
+// encrypted by passwd, the existing wallets are not changed and reported as conflicts, returns
+// {"imported":["spo_24argCsVuBMYEBr6"],"conflicts":["skycoin_24argCsVuBMYEBr6"]}
+func ImportBackup(blob, backupPasswd, passwd string) (string, error) {
+	report, err := wallet.ImportBackup(blob, backupPasswd, passwd)
+	if err != nil {
+		return "", err
+	}
+	d, err := json.Marshal(report)
+	if err != nil {
+		return "", err
+	}
+	return string(d), nil
+}
+
+// SplitSeed split the seed of unlocked wallet into count shares, any threshold of them
+// restore the wallet by RestoreFromShares, returns {"shares":["0112340200...","0112340201..."]}
+func SplitSeed(walletID string, threshold, count int) (string, error) {
+	shares, err := wallet.SplitSeed(walletID, threshold, count)
+	if err != nil {
+		return "", err
+	}
+	var res = struct {
+		Shares []string `json:"shares"`
+	}{
+		shares,
+	}
+	d, err := json.Marshal(res)
+	if err != nil {
+		return "", err
+	}
+	return string(d), nil
+}
+
+// RestoreFromShares restore the wallet from the shares (format "s1,s2,s3") of SplitSeed,
+// the passphrase is not in the shares, returns the wallet id.
+func RestoreFromShares(coinType, lable, shares, passphrase, passwd string) (string, error) {
+	if len(passwd) == 0 {
+		return "", errors.New("password cannot empty")
+	}
+	wlt, err := wallet.RestoreFromShares(coinType, lable, strings.Split(shares, ","), passphrase, passwd)
+	if err != nil {
+		return "", err
+	}
+	return wlt.GetID(), nil
+}
+
+// NewPaperWallet generate a paper wallet of random secret key, or of fresh seed if withSeed,
+// the page is a self-contained svg with the QR codes of address and secret, returns
+// {"coin_type":"spo","address":"2GgFvqoyk9RjwVzj8tqfcXVXB4orBwoc9qv","seckey":"a03dc39c...","svg":"<?xml..."}
+func NewPaperWallet(coinType string, withSeed bool) (string, error) {
+	var pw paper.Wallet
+	var err error
+	if withSeed {
+		pw, err = paper.NewWithSeed(coinType)
+	} else {
+		pw, err = paper.New(coinType)
+	}
+	if err != nil {
+		return "", err
+	}
+	svg, err := pw.SVG()
+	if err != nil {
+		return "", err
+	}
+	var res = struct {
+		paper.Wallet
+		SVG string `json:"svg"`
+	}{
+		pw,
+		svg,
+	}
+	d, err := json.Marshal(res)
+	if err != nil {
+		return "", err
+	}
+	return string(d), nil
+}
+
 // IsExist wallet exists or not
 func IsExist(walletID string) bool {
 	return wallet.IsExist(walletID)
 }
 
-// IsContain wallet contains address (format "a1,a2,a3") or not
+// IsContain wallet contains address (format "a1,a2,a3") or not, the addresses of the
+// unverified wallet aren't authenticated, check IsVerified before trusting the result.
 func IsContain(walletID string, addrs string) (bool, error) {
 	addresses := strings.Split(addrs, ",")
 	return wallet.IsContain(walletID, addresses)
 }
 
-// NewAddress generate address in specific wallet.
+// NewAddress generate address in specific wallet, passwd can be empty if the wallet is unlocked.
 func NewAddress(walletID string, num int, passwd string) (string, error) {
-	if len(passwd) == 0 {
+	if len(passwd) == 0 && !wallet.IsUnlocked(walletID) {
 		return "", errors.New("password cannot empty")
 	}
 	es, err := wallet.NewAddresses(walletID, num, passwd)
@@ -109,17 +421,57 @@ func NewAddress(walletID string, num int, passwd string) (string, error) {
 	return string(d), nil
 }
 
-// GetAddresses return all addresses in the wallet.
-// returns {"addresses":["jvzYqvdZs17i67cxZ5R8zGE4446JGPVYyz","FNhfaxwWgDVfuXdn2kUoMkxpDFGvqoSPzq","5spraVxAAkFC9j1cpMEdMu7CoV3iHRG7pG"]}
+// ChangePassword change the password of specific wallet.
+func ChangePassword(walletID, oldPasswd, newPasswd string) error {
+	if len(newPasswd) == 0 {
+		return errors.New("password cannot empty")
+	}
+	return wallet.ChangePassword(walletID, oldPasswd, newPasswd)
+}
+
+// ChangeAllPasswords change the password of all wallets, either all wallets
+// are changed or none of them.
+func ChangeAllPasswords(oldPasswd, newPasswd string) error {
+	if len(newPasswd) == 0 {
+		return errors.New("password cannot empty")
+	}
+	return wallet.ChangeAllPasswords(oldPasswd, newPasswd)
+}
+
+// Unlock keeps the wallet unlocked for ttl seconds, NewAddress, GetKeyPairOfAddr, GetSeed
+// and Send of the unlocked wallet accept empty password and don't decrypt the wallet again.
+func Unlock(walletID, passwd string, ttl int) error {
+	return wallet.Unlock(walletID, passwd, time.Duration(ttl)*time.Second)
+}
+
+// Lock locks the unlocked wallet before the ttl expires.
+func Lock(walletID string) error {
+	return wallet.Lock(walletID)
+}
+
+// IsVerified checks the public fields of the wallet, such as addresses and lable, were verified
+// by the mac since loaded, the wallet is verified once it's decrypted by the password.
+func IsVerified(walletID string) (bool, error) {
+	return wallet.IsVerified(walletID)
+}
+
+// GetAddresses return all addresses in the wallet, verified is false if the mac of the
+// wallet hasn't been checked since loaded.
+// returns {"addresses":["jvzYqvdZs17i67cxZ5R8zGE4446JGPVYyz","FNhfaxwWgDVfuXdn2kUoMkxpDFGvqoSPzq","5spraVxAAkFC9j1cpMEdMu7CoV3iHRG7pG"],"verified":true}
 func GetAddresses(walletID string) (string, error) {
-	addrs, err := wallet.GetAddresses(walletID)
+	entries, verified, err := wallet.GetVerifiedAddressEntries(walletID)
 	if err != nil {
 		return "", err
 	}
 	var res = struct {
 		Addresses []string `json:"addresses"`
+		Verified  bool     `json:"verified"`
 	}{
-		addrs,
+		Addresses: []string{},
+		Verified:  verified,
+	}
+	for _, e := range entries {
+		res.Addresses = append(res.Addresses, e.Address)
 	}
 
 	d, err := json.Marshal(res)
@@ -130,7 +482,73 @@ func GetAddresses(walletID string) (string, error) {
 	return string(d), nil
 }
 
-// Remove delete wallet.
+// GetAddressesInfo return the addresses in the wallet with their metadata and the verified flag, returns
+// {"addresses":[{"address":"2GgFvqoyk9RjwVzj8tqfcXVXB4orBwoc9qv","pubkey":"02...","label":"Savings","index":0,"created":1532312341,"used":true}],"verified":true}
+func GetAddressesInfo(walletID string) (string, error) {
+	entries, verified, err := wallet.GetVerifiedAddressEntries(walletID)
+	if err != nil {
+		return "", err
+	}
+	type addressInfo struct {
+		Address string `json:"address"`
+		Public  string `json:"pubkey"`
+		Label   string `json:"label"`
+		Index   int    `json:"index"`
+		Created int64  `json:"created"`
+		Used    bool   `json:"used"`
+	}
+	var res = struct {
+		Addresses []addressInfo `json:"addresses"`
+		Verified  bool          `json:"verified"`
+	}{
+		Addresses: []addressInfo{},
+		Verified:  verified,
+	}
+	for _, e := range entries {
+		res.Addresses = append(res.Addresses, addressInfo{
+			Address: e.Address,
+			Public:  e.Public,
+			Label:   e.Label,
+			Index:   e.Index,
+			Created: e.Created,
+			Used:    e.Used,
+		})
+	}
+	d, err := json.Marshal(res)
+	if err != nil {
+		return "", err
+	}
+	return string(d), nil
+}
+
+// SetAddressLabel set the label of address, such as "Savings", empty label clears it.
+func SetAddressLabel(walletID, addr, label string) error {
+	return wallet.SetAddressLabel(walletID, addr, label)
+}
+
+// SetAddressUsed set whether the address has received coins.
+func SetAddressUsed(walletID, addr string, used bool) error {
+	return wallet.SetAddressesUsed(walletID, map[string]bool{addr: used})
+}
+
+// RefreshAddressUsage queries the coin node and updates the used flag of all addresses in the wallet.
+func RefreshAddressUsage(coinType, walletID string) error {
+	cn, ok := coinMap[coinType]
+	if !ok {
+		return fmt.Errorf("%s is not supported", coinType)
+	}
+	addrs, err := wallet.GetAddresses(walletID)
+	if err != nil {
+		return err
+	}
+	used, err := cn.UsedAddresses(addrs)
+	if err != nil {
+		return err
+	}
+	return wallet.SetAddressesUsed(walletID, used)
+}
+
+// Remove delete wallet, the linked wallets created by NewMultiCoinWallet are removed together.
 func Remove(walletID string) error {
 	return wallet.Remove(walletID)
 }
@@ -156,6 +574,17 @@ func GetKeyPairOfAddr(walletID, addr, passwd string) (string, error) {
 	return string(d), nil
 }
 
+// GetSeckeyOfAddr get the raw seckey of address in specific wallet, unlike the hex string of
+// GetKeyPairOfAddr, the caller can zero the returned bytes after use.
+func GetSeckeyOfAddr(walletID, addr, passwd string) ([]byte, error) {
+	sec, err := wallet.GetSeckey(walletID, addr, passwd)
+	if err != nil {
+		return nil, err
+	}
+	defer secure.Wipe(sec)
+	return append([]byte{}, sec...), nil
+}
+
 // GetBalance return balance of a specific address.
 // returns {"balance":"70.000000", "hours": "32001"}
 func GetBalance(coinType string, address string) (string, error) {
@@ -199,17 +628,22 @@ func GetBalance(coinType string, address string) (string, error) {
 	return string(d), nil
 }
 
-// GetWalletBalance return balance of wallet.
+// GetWalletBalance return balance of wallet, verified is false if the addresses of the
+// wallet haven't been checked by the mac since loaded.
 func GetWalletBalance(coinType string, wltID string) (string, error) {
 	coin, ok := coinMap[coinType]
 	if !ok {
 		return "", fmt.Errorf("%s is not supported", coinType)
 	}
 
-	addrs, err := wallet.GetAddresses(wltID)
+	entries, verified, err := wallet.GetVerifiedAddressEntries(wltID)
 	if err != nil {
 		return "", err
 	}
+	addrs := make([]string, 0, len(entries))
+	for _, e := range entries {
+		addrs = append(addrs, e.Address)
+	}
 
 	bal, err := coin.GetBalance(strings.Join(addrs, ","))
 	if err != nil {
@@ -227,11 +661,13 @@ func GetWalletBalance(coinType string, wltID string) (string, error) {
 
 	hours := int64(b.Confirmed.Hours)
 	var res = struct {
-		Balance string `json:"balance"`
-		Hours   int64  `json:"hours"`
+		Balance  string `json:"balance"`
+		Hours    int64  `json:"hours"`
+		Verified bool   `json:"verified"`
 	}{
 		coins,
 		hours,
+		verified,
 	}
 
 	d, err := json.Marshal(res)
@@ -251,6 +687,28 @@ func Send(coinType, wid, toAddr, amount, passwd string) (string, error) {
 	return coin.Send(wid, toAddr, amount, passwd)
 }
 
+// SendWithChange send coins with change policy, changePolicy is one of "fresh", "fixed"
+// and "first_input". The fresh change address is derived from the wallet and saved before
+// the transaction is broadcast, changeAddr is the change address of "fixed" policy.
+func SendWithChange(coinType, wid, toAddr, amount, passwd, changePolicy, changeAddr string) (string, error) {
+	coin, ok := coinMap[coinType]
+	if !ok {
+		return "", fmt.Errorf("%s is not supported", coinType)
+	}
+
+	return coin.SendWithChange(wid, toAddr, amount, passwd, changePolicy, changeAddr)
+}
+
+// Sweep send all coins of the paper wallet to the first address of the wallet, secret is
+// the secret key or seed printed on the paper wallet.
+func Sweep(coinType, secret, walletID string) (string, error) {
+	coin, ok := coinMap[coinType]
+	if !ok {
+		return "", fmt.Errorf("%s is not supported", coinType)
+	}
+	return coin.Sweep(secret, walletID)
+}
+
 // GetTransactionByID gets transaction verbose info by id
 func GetTransactionByID(coinType, txid string) (string, error) {
 	coin, ok := coinMap[coinType]
@@ -290,14 +748,38 @@ func NewSeed() string {
 	return wallet.NewSeed()
 }
 
+// NewSeedWithOptions generates mnemonic seed of entropyBits in the wordlist of language,
+// entropyBits can be 128, 160, 192, 224 or 256, language can be english, chinese_simplified,
+// chinese_traditional, japanese, spanish, french, italian or korean.
+func NewSeedWithOptions(entropyBits int, language string) (string, error) {
+	return wallet.NewSeedWithOptions(entropyBits, language)
+}
+
 // GetSeed returun wallet seed
 func GetSeed(walletID, passwd string) (string, error) {
 	return wallet.GetSeed(walletID, passwd)
 }
 
-func getPrivateKey(walletID, passwd string) coin.GetPrivKey {
-	return func(addr string) (string, error) {
-		_, s, err := wallet.GetKeypair(walletID, addr, passwd)
-		return s, err
+// GetSeedBytes return wallet seed in bytes, unlike the string of GetSeed, the caller can
+// zero the returned bytes after use.
+func GetSeedBytes(walletID, passwd string) ([]byte, error) {
+	seed, err := wallet.GetSeedBytes(walletID, passwd)
+	if err != nil {
+		return nil, err
+	}
+	defer secure.Wipe(seed)
+	return append([]byte{}, seed...), nil
+}
+
+// getPrivateKey returns the copy of the key of addr among the keys decrypted for the
+// transaction, the caller wipes the copy after signing.
+func getPrivateKey(addrs []string, secs [][]byte) coin.GetPrivKey {
+	return func(addr string) ([]byte, error) {
+		for i, a := range addrs {
+			if a == addr {
+				return secure.Copy(secs[i]), nil
+			}
+		}
+		return nil, fmt.Errorf("%s addr does not exist in wallet", addr)
 	}
 }
@@ -1,11 +1,15 @@
 package mobile
 
 import (
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/MDLlife/wallet-api/src/wallet"
 	"github.com/skycoin/skycoin/src/util/file"
 	"github.com/stretchr/testify/assert"
 )
@@ -27,11 +31,37 @@ func TestWrongPassword(t *testing.T) {
 
 	originSeed := "ab 12 57 xx yy zz hh oo"
 
-	wlt, err := NewWallet("spo", "rightlable", originSeed, rightPassword)
+	// arbitrary seed needs the brain wallet opt-in.
+	_, err = NewWallet("spo", "rightlable", originSeed, rightPassword)
+	assert.EqualError(t, err, "invalid mnemonic, 8 words, must be 12, 15, 18, 21 or 24 words")
+	assert.Error(t, ValidateSeed(originSeed))
+
+	wlt, err := NewBrainWallet("spo", "rightlable", originSeed, rightPassword)
 	assert.NoError(t, err)
 	assert.Equal(t, "spo_24argCsVuBMYEBr6", wlt)
 
 	assert.True(t, IsExist(wlt))
+
+	// every wallet has its own password
+	otherPassword := "abcdefgh12345678"
+	otherWlt, err := NewBrainWallet("spo", "otherlable", "ab 12 57 xx yy zz hh pp", otherPassword)
+	assert.NoError(t, err)
+
+	_, err = NewAddress(otherWlt, 1, rightPassword)
+	assert.EqualError(t, err, "wallet password incorrect")
+	_, err = NewAddress(otherWlt, 1, otherPassword)
+	assert.NoError(t, err)
+	_, err = NewAddress(wlt, 1, otherPassword)
+	assert.EqualError(t, err, "wallet password incorrect")
+
+	_, err = GetSeed(otherWlt, rightPassword)
+	assert.EqualError(t, err, "wallet password incorrect")
+	seed, err := GetSeed(otherWlt, otherPassword)
+	assert.NoError(t, err)
+	assert.Equal(t, "ab 12 57 xx yy zz hh pp", seed)
+
+	err = Remove(otherWlt)
+	assert.NoError(t, err)
 	err = Remove(wlt)
 	assert.NoError(t, err)
 
@@ -51,7 +81,7 @@ func TestMobileApi(t *testing.T) {
 	var err error
 
 	coinTypes := GetSupportedCoin()
-	assert.Equal(t, "skycoin,spo,suncoin,shellcoin,mzcoin,aynrandcoin", coinTypes)
+	assert.Equal(t, "skycoin,samos,spo,suncoin,shellcoin,mzcoin,aynrandcoin,mdl", coinTypes)
 	password := "12345678abcdefgh"
 	err = Init(tmpDir, password)
 	assert.NoError(t, err)
@@ -63,14 +93,14 @@ func TestMobileApi(t *testing.T) {
 	originSeed := "abcd 1234 8909 bcde xmme adbn nw we hell world then at"
 	wlt = "spo_lableandseed"
 	if !IsExist(wlt) {
-		wlt, err = NewWallet("spo", "lableandseed", originSeed, password)
+		wlt, err = NewBrainWallet("spo", "lableandseed", originSeed, password)
 		assert.NoError(t, err)
 		assert.Equal(t, "spo_3nfw5uwWtktbNbGd", wlt)
 
-		// wrong password
-		_, err = NewWallet("spo", "lableandseed_123", originSeed, "12345678abcdabcd")
+		// same seed with another password
+		_, err = NewBrainWallet("spo", "lableandseed_123", originSeed, "12345678abcdabcd")
 		assert.Error(t, err)
-		assert.Equal(t, "wallet password incorrect", err.Error())
+		assert.Equal(t, "spo_3nfw5uwWtktbNbGd already exist", err.Error())
 
 		addresses, err := NewAddress(wlt, 2, password)
 		expectAddrs := "{\"addresses\":[{\"address\":\"2fwZKXRU9PAQ7TRxVzj2MTE9uz9gvccLEGZ\",\"pubkey\":\"032979cd01374e1160cb4da6176e95ab4b0017a409a34ab121f3f76595c6d6459d\",\"seckey\":\"\"},{\"address\":\"27QMsG95g3u2rFnfqoJhYF7ZFJttx1ZQYg9\",\"pubkey\":\"02620ba4c261ce12210ca791ffc234a36119e9cc14071d3cd3a5934c98c5026a7b\",\"seckey\":\"\"}]}"
@@ -84,7 +114,7 @@ func TestMobileApi(t *testing.T) {
 	}
 	addresses, err := GetAddresses(wlt)
 	assert.NoError(t, err)
-	expectAddresses := "{\"addresses\":[\"3nfw5uwWtktbNbGdx5cNF4i4GRUqp53Rtr\",\"2fwZKXRU9PAQ7TRxVzj2MTE9uz9gvccLEGZ\",\"27QMsG95g3u2rFnfqoJhYF7ZFJttx1ZQYg9\"]}"
+	expectAddresses := "{\"addresses\":[\"3nfw5uwWtktbNbGdx5cNF4i4GRUqp53Rtr\",\"2fwZKXRU9PAQ7TRxVzj2MTE9uz9gvccLEGZ\",\"27QMsG95g3u2rFnfqoJhYF7ZFJttx1ZQYg9\"],\"verified\":true}"
 	assert.Equal(t, expectAddresses, addresses)
 
 	addr := "3nfw5uwWtktbNbGdx5cNF4i4GRUqp53Rtr"
@@ -92,8 +122,127 @@ func TestMobileApi(t *testing.T) {
 	assert.NoError(t, err)
 	expectPair := "{\"pubkey\":\"02ba3470b34ad121ae4ac8036d76ed33b80d03c2d43aca4ad3947220053af11969\",\"seckey\":\"a03dc39c34c1f715658de0e6ffae66c02f5871b578834b3b18882a73ccc8dad9\"}"
 	assert.Equal(t, expectPair, pair)
+	sec, err := GetSeckeyOfAddr(wlt, addr, password)
+	assert.NoError(t, err)
+	assert.Equal(t, "a03dc39c34c1f715658de0e6ffae66c02f5871b578834b3b18882a73ccc8dad9", hex.EncodeToString(sec))
 
 	seed1, err := GetSeed(wlt, password)
 	assert.NoError(t, err)
 	assert.Equal(t, originSeed, seed1)
+	seed2, err := GetSeedBytes(wlt, password)
+	assert.NoError(t, err)
+	assert.Equal(t, originSeed, string(seed2))
+
+	// the reloaded wallet is unverified until it's decrypted by the password.
+	assert.NoError(t, LoadWallet(""))
+	addresses, err = GetAddresses(wlt)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Replace(expectAddresses, `"verified":true`, `"verified":false`, 1), addresses)
+	info, err := GetAddressesInfo(wlt)
+	assert.NoError(t, err)
+	assert.Contains(t, info, `"verified":false`)
+	verified, err := IsVerified(wlt)
+	assert.NoError(t, err)
+	assert.False(t, verified)
+	_, err = GetSeed(wlt, password)
+	assert.NoError(t, err)
+	verified, err = IsVerified(wlt)
+	assert.NoError(t, err)
+	assert.True(t, verified)
+}
+
+func TestMultiCoinWallet(t *testing.T) {
+	tmpDir := filepath.Join(os.TempDir(), ".wallet1003")
+	defer os.RemoveAll(tmpDir)
+	err := Init(tmpDir, "")
+	assert.NoError(t, err)
+
+	_, err = NewMultiCoinWallet("", "", "")
+	assert.EqualError(t, err, "password cannot empty")
+
+	ret, err := NewMultiCoinWallet("", "12345678", "")
+	assert.NoError(t, err)
+	var res struct {
+		Group   string   `json:"group"`
+		Wallets []string `json:"wallets"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(ret), &res))
+	assert.True(t, strings.HasPrefix(res.Group, "group_"))
+	assert.Len(t, res.Wallets, len(strings.Split(GetSupportedCoin(), ",")))
+	seed, err := GetSeed(res.Wallets[0], "12345678")
+	assert.NoError(t, err)
+	for _, id := range res.Wallets[1:] {
+		s, err := GetSeed(id, "12345678")
+		assert.NoError(t, err)
+		assert.Equal(t, seed, s)
+	}
+
+	group, err := GetGroupWallets(res.Group)
+	assert.NoError(t, err)
+	assert.JSONEq(t, ret, group)
+
+	assert.NoError(t, Remove(res.Wallets[0]))
+	for _, id := range res.Wallets {
+		assert.False(t, IsExist(id))
+	}
+}
+
+func TestBackup(t *testing.T) {
+	tmpDir := filepath.Join(os.TempDir(), ".wallet1004")
+	defer os.RemoveAll(tmpDir)
+	err := Init(tmpDir, "")
+	assert.NoError(t, err)
+
+	wlt, err := NewWallet("spo", "l1", "", "12345678")
+	assert.NoError(t, err)
+	res, err := ExportBackup("12345678", "backup")
+	assert.NoError(t, err)
+	var exported struct {
+		Backup  string   `json:"backup"`
+		Skipped []string `json:"skipped"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(res), &exported))
+	assert.Empty(t, exported.Skipped)
+	blob := exported.Backup
+
+	loaded, err := InitWithReport(filepath.Join(tmpDir, "new"))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"loaded":[],"skipped":[],"corrupt":[],"recovered":[]}`, loaded)
+	assert.False(t, IsExist(wlt))
+	report, err := ImportBackup(blob, "backup", "87654321")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"imported":["`+wlt+`"],"conflicts":[]}`, report)
+	assert.NoError(t, wallet.VerifyPassword(wlt, "87654321"))
+}
+
+func TestSplitSeed(t *testing.T) {
+	tmpDir := filepath.Join(os.TempDir(), ".wallet1005")
+	defer os.RemoveAll(tmpDir)
+	err := Init(tmpDir, "")
+	assert.NoError(t, err)
+
+	wlt, err := NewWallet("spo", "l1", "", "12345678")
+	assert.NoError(t, err)
+	seed, err := GetSeed(wlt, "12345678")
+	assert.NoError(t, err)
+	assert.NoError(t, Unlock(wlt, "12345678", 60))
+	res, err := SplitSeed(wlt, 2, 3)
+	assert.NoError(t, err)
+	var v struct {
+		Shares []string `json:"shares"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(res), &v))
+	assert.Len(t, v.Shares, 3)
+
+	assert.NoError(t, Remove(wlt))
+	_, err = RestoreFromShares("spo", "l1", v.Shares[1], "", "12345678")
+	assert.EqualError(t, err, "need 2 shares, got 1")
+	_, err = RestoreFromShares("spo", "l1", v.Shares[1]+","+v.Shares[1], "", "12345678")
+	assert.EqualError(t, err, "duplicate share 2")
+	id, err := RestoreFromShares("spo", "l1", v.Shares[2]+","+v.Shares[0], "", "12345678")
+	assert.NoError(t, err)
+	assert.Equal(t, wlt, id)
+	restored, err := GetSeed(id, "12345678")
+	assert.NoError(t, err)
+	assert.Equal(t, seed, restored)
 }
@@ -20,6 +20,8 @@ import (
 	"github.com/skycoin/skycoin/src/wallet"
 	"github.com/MDLlife/wallet-api/src/coin"
 	"github.com/MDLlife/wallet-api/src/coin/skycoin"
+	"github.com/MDLlife/wallet-api/src/paper"
+	"github.com/MDLlife/wallet-api/src/util/secure"
 	walletex "github.com/MDLlife/wallet-api/src/wallet"
 )
 
@@ -37,6 +39,17 @@ const (
 	BurnFactor int64 = 2
 )
 
+// Change policies decide the address which receives the change of transaction.
+const (
+	// ChangeFresh derives a new address from the wallet for the change.
+	ChangeFresh = "fresh"
+	// ChangeFixed sends the change to the address chosen by the user.
+	ChangeFixed = "fixed"
+	// ChangeFirstInput sends the change back to the address of the first input,
+	// which links the payments, it's the behaviour of Send.
+	ChangeFirstInput = "first_input"
+)
+
 func init() {
 	// Compute maxDropletDivisor from precision
 	maxDropletDivisor = calculateDivisor(int64(visor.MaxDropletPrecision))
@@ -75,6 +88,9 @@ type Coiner interface {
 	GetNodeAddr() string
 	IsTransactionConfirmed(txid string) (bool, error)
 	Send(walletID, toAddr, amount, passwd string) (string, error)
+	SendWithChange(walletID, toAddr, amount, passwd, changePolicy, changeAddr string) (string, error)
+	UsedAddresses(addrs []string) (map[string]bool, error)
+	Sweep(secret, walletID string) (string, error)
 }
 
 // CoinEx implements the Coin interface.
@@ -84,9 +100,12 @@ type coinEx struct {
 }
 
 type sendParams struct {
-	WalletID string
-	ToAddr   string
-	Amount   uint64
+	WalletID     string
+	ToAddr       string
+	Amount       uint64
+	Passwd       string
+	ChangePolicy string
+	ChangeAddr   string
 }
 
 func newCoin(name, nodeAddr string) *coinEx {
@@ -200,6 +219,45 @@ func (cn coinEx) GetBalance(addrs string) (string, error) {
 
 }
 
+// UsedAddresses returns the addresses which have received coins, every used
+// address has received coins before spending.
+func (cn coinEx) UsedAddresses(addrs []string) (map[string]bool, error) {
+	url := fmt.Sprintf("http://%s/transactions?addrs=%s", cn.nodeAddr, strings.Join(addrs, ","))
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get transactions failed, status: %s", resp.Status)
+	}
+
+	txns := []struct {
+		Txn struct {
+			Outputs []struct {
+				Address string `json:"dst"`
+			} `json:"outputs"`
+		} `json:"txn"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&txns); err != nil {
+		return nil, err
+	}
+
+	used := make(map[string]bool, len(addrs))
+	for _, a := range addrs {
+		used[a] = false
+	}
+	for _, tx := range txns {
+		for _, o := range tx.Txn.Outputs {
+			if _, ok := used[o.Address]; ok {
+				used[o.Address] = true
+			}
+		}
+	}
+	return used, nil
+}
+
 // ValidateAddr check if the address is validated
 func (cn coinEx) ValidateAddr(address string) error {
 	_, err := cipher.DecodeBase58Address(address)
@@ -236,16 +294,22 @@ func (cn coinEx) CreateRawTx(txIns []coin.TxIn, getKey coin.GetPrivKey, txOuts i
 	}
 
 	keys := make([]cipher.SecKey, len(txIns))
+	defer func() {
+		for i := range keys {
+			secure.Wipe(keys[i][:])
+		}
+	}()
 	for i, in := range txIns {
 		s, err := getKey(in.Address)
 		if err != nil {
 			return "", fmt.Errorf("get private key failed:%v", err)
 		}
-		k, err := cipher.SecKeyFromHex(s)
-		if err != nil {
-			return "", fmt.Errorf("invalid private key:%v", err)
+		if len(s) != len(keys[i]) {
+			secure.Wipe(s)
+			return "", errors.New("invalid private key: invalid length")
 		}
-		keys[i] = k
+		copy(keys[i][:], s)
+		secure.Wipe(s)
 	}
 
 	tx.SignInputs(keys)
@@ -288,6 +352,27 @@ func (cn coinEx) BroadcastTx(rawtx string) (string, error) {
 
 }
 
+// changeAddr returns the change address of the policy, the fresh address is
+// stored in the wallet file before it's used in the transaction.
+func changeAddr(p sendParams, uxBal []wallet.UxBalance) (string, error) {
+	switch p.ChangePolicy {
+	case ChangeFresh:
+		entries, err := walletex.NewAddresses(p.WalletID, 1, p.Passwd)
+		if err != nil {
+			return "", fmt.Errorf("derive change address failed: %v", err)
+		}
+		return entries[0].Address, nil
+	case ChangeFixed:
+		return p.ChangeAddr, nil
+	default:
+		addr, err := getChangeAddr(uxBal)
+		if err != nil {
+			return "", ErrNoChangeAddr
+		}
+		return addr, nil
+	}
+}
+
 func getChangeAddr(uxBal []wallet.UxBalance) (string, error) {
 	// this will not be happened
 	if len(uxBal) == 0 {
@@ -312,6 +397,16 @@ func (cn coinEx) PrepareTx(params interface{}) ([]coin.TxIn, interface{}, error)
 		return nil, nil, err
 	}
 
+	switch p.ChangePolicy {
+	case ChangeFixed:
+		if err := cn.ValidateAddr(p.ChangeAddr); err != nil {
+			return nil, nil, fmt.Errorf("invalid change address %s", p.ChangeAddr)
+		}
+	case ChangeFresh, ChangeFirstInput, "":
+	default:
+		return nil, nil, fmt.Errorf("unknown change policy %s", p.ChangePolicy)
+	}
+
 	addrs, err := walletex.GetAddresses(p.WalletID)
 	if err != nil {
 		return nil, nil, err
@@ -359,9 +454,9 @@ func (cn coinEx) PrepareTx(params interface{}) ([]coin.TxIn, interface{}, error)
 	chgHours, addrHours := distributeSpendHours(hours, haveChange)
 
 	if chgAmt > 0 {
-		chgAddr, err := getChangeAddr(uxBalances)
+		chgAddr, err := changeAddr(p, uxBalances)
 		if err != nil {
-			return nil, nil, ErrNoChangeAddr
+			return nil, nil, err
 		}
 		txOut = append(txOut,
 			cn.makeTxOut(p.ToAddr, p.Amount, addrHours),
@@ -406,8 +501,15 @@ func distributeSpendHours(inputHours uint64, haveChange bool) (uint64, uint64) {
 	return changeHours, addrHours
 }
 
-// Send sends numbers of coins to toAddr from specific wallet
+// Send sends numbers of coins to toAddr from specific wallet, the change goes
+// back to the address of the first input.
 func (cn *coinEx) Send(walletID, toAddr, amount, passwd string) (string, error) {
+	return cn.SendWithChange(walletID, toAddr, amount, passwd, ChangeFirstInput, "")
+}
+
+// SendWithChange sends numbers of coins to toAddr from specific wallet, the change
+// address is decided by changePolicy, changeAddr is only used by ChangeFixed.
+func (cn *coinEx) SendWithChange(walletID, toAddr, amount, passwd, changePolicy, changeAddr string) (string, error) {
 	// validate amount
 	amt, err := droplet.FromString(amount)
 	if err != nil {
@@ -424,15 +526,35 @@ func (cn *coinEx) Send(walletID, toAddr, amount, passwd string) (string, error)
 		return "", err
 	}
 
-	params := sendParams{WalletID: walletID, ToAddr: toAddr, Amount: amt}
+	params := sendParams{
+		WalletID:     walletID,
+		ToAddr:       toAddr,
+		Amount:       amt,
+		Passwd:       passwd,
+		ChangePolicy: changePolicy,
+		ChangeAddr:   changeAddr,
+	}
 
 	txIns, txOut, err := cn.PrepareTx(params)
 	if err != nil {
 		return "", err
 	}
 
-	// prepare keys
-	rawtx, err := cn.CreateRawTx(txIns, getPrivateKey(walletID, passwd), txOut)
+	// prepare keys, the wallet is decrypted once for the keys of all inputs.
+	addrs := make([]string, len(txIns))
+	for i, in := range txIns {
+		addrs[i] = in.Address
+	}
+	secs, err := walletex.GetSeckeys(walletID, addrs, passwd)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		for _, s := range secs {
+			secure.Wipe(s)
+		}
+	}()
+	rawtx, err := cn.CreateRawTx(txIns, getPrivateKey(addrs, secs), txOut)
 	if err != nil {
 		return "", fmt.Errorf("create raw transaction failed:%v", err)
 	}
@@ -444,6 +566,82 @@ func (cn *coinEx) Send(walletID, toAddr, amount, passwd string) (string, error)
 	return fmt.Sprintf(`{"txid":%s}`, txid), nil
 }
 
+// Sweep sends all coins of the paper wallet to the first address of the wallet, secret is
+// the hex secret key or the seed printed on the paper wallet.
+func (cn *coinEx) Sweep(secret, walletID string) (string, error) {
+	if tp := strings.Split(walletID, "_")[0]; tp != cn.name {
+		return "", fmt.Errorf("invalid wallet %v", tp)
+	}
+	addrs, err := walletex.GetAddresses(walletID)
+	if err != nil {
+		return "", err
+	}
+	if len(addrs) == 0 {
+		return "", errors.New("wallet has no address")
+	}
+
+	entry, seckey, err := paper.ParseSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	defer secure.Wipe(seckey)
+
+	txIns, txOut, err := cn.prepareSweep(entry.Address, addrs[0])
+	if err != nil {
+		return "", err
+	}
+	rawtx, err := cn.CreateRawTx(txIns, func(addr string) ([]byte, error) {
+		if addr != entry.Address {
+			return nil, fmt.Errorf("%s is not the address of paper wallet", addr)
+		}
+		return secure.Copy(seckey), nil
+	}, txOut)
+	if err != nil {
+		return "", fmt.Errorf("create raw transaction failed:%v", err)
+	}
+
+	txid, err := cn.BroadcastTx(rawtx)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`{"txid":%s}`, txid), nil
+}
+
+// prepareSweep spends all spendable outputs of addr to toAddr, the fee is burned from the hours.
+func (cn coinEx) prepareSweep(addr, toAddr string) ([]coin.TxIn, interface{}, error) {
+	utxosStr, err := cn.getOutputs([]string{addr})
+	if err != nil {
+		return nil, nil, err
+	}
+	utxos := visor.ReadableOutputSet{}
+	if err := json.Unmarshal([]byte(utxosStr), &utxos); err != nil {
+		return nil, nil, err
+	}
+	uxBalances, err := visor.ReadableOutputsToUxBalances(utxos.SpendableOutputs())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var coins, hours uint64
+	txIns := make([]coin.TxIn, len(uxBalances))
+	for i, u := range uxBalances {
+		coins += u.Coins
+		hours += u.Hours
+		txIns[i] = coin.TxIn{
+			Txid:    u.Hash.Hex(),
+			Address: u.Address.String(),
+		}
+	}
+	if coins == 0 {
+		return nil, nil, errors.New("no coins to sweep")
+	}
+	if hours == 0 {
+		return nil, nil, ErrTxnNoFee
+	}
+	_, addrHours := distributeSpendHours(hours, false)
+	return txIns, []skycoin.TxOut{cn.makeTxOut(toAddr, coins, addrHours)}, nil
+}
+
 func (cn coinEx) makeTxOut(addr string, coins uint64, hours uint64) skycoin.TxOut {
 	out := skycoin.TxOut{}
 	out.Address = cipher.MustDecodeBase58Address(addr)
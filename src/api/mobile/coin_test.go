@@ -1,8 +1,20 @@
 package mobile
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/MDLlife/wallet-api/src/coin"
+	"github.com/MDLlife/wallet-api/src/coin/skycoin"
+	"github.com/MDLlife/wallet-api/src/util/secure"
+	walletex "github.com/MDLlife/wallet-api/src/wallet"
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/wallet"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -36,3 +48,219 @@ func TestCoinHour(t *testing.T) {
 	assert.Equal(t, uint64(0), chgHours)
 	assert.Equal(t, uint64(5), addrHours)
 }
+
+func TestCreateRawTxWipeKeys(t *testing.T) {
+	pub, sec := cipher.GenerateKeyPair()
+	addr := cipher.AddressFromPubKey(pub).String()
+	cn := newCoin("spo", "")
+
+	keys := [][]byte{}
+	getKey := func(k []byte) coin.GetPrivKey {
+		return func(string) ([]byte, error) {
+			keys = append(keys, k)
+			return k, nil
+		}
+	}
+	txIns := []coin.TxIn{{Txid: strings.Repeat("ab", 32), Address: addr}}
+	txOuts := []skycoin.TxOut{cn.makeTxOut(addr, 1e3, 1)}
+
+	rawtx, err := cn.CreateRawTx(txIns, getKey(secure.Copy(sec[:])), txOuts)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, rawtx)
+
+	// key of invalid length is wiped too.
+	_, err = cn.CreateRawTx(txIns, getKey(secure.Copy(sec[:31])), txOuts)
+	assert.Error(t, err)
+
+	assert.Len(t, keys, 2)
+	for _, k := range keys {
+		assert.True(t, secure.IsWiped(k))
+	}
+}
+
+func TestUsedAddresses(t *testing.T) {
+	var used string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/transactions" {
+			http.NotFound(w, r)
+			return
+		}
+		var txns []string
+		for _, a := range strings.Split(r.URL.Query().Get("addrs"), ",") {
+			if a == used {
+				txns = append(txns, `{"txn":{"outputs":[{"dst":"`+a+`","coins":"1.000000"}]}}`)
+			}
+		}
+		w.Write([]byte("[" + strings.Join(txns, ",") + "]"))
+	}))
+	defer srv.Close()
+	nodeAddr := strings.TrimPrefix(srv.URL, "http://")
+
+	pub1, _ := cipher.GenerateDeterministicKeyPair([]byte("used1"))
+	pub2, _ := cipher.GenerateDeterministicKeyPair([]byte("used2"))
+	addr1 := cipher.AddressFromPubKey(pub1).String()
+	addr2 := cipher.AddressFromPubKey(pub2).String()
+
+	used = addr2
+	cn := newCoin("spo", nodeAddr)
+	m, err := cn.UsedAddresses([]string{addr1, addr2})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{addr1: false, addr2: true}, m)
+
+	_, err = newCoin("spo", nodeAddr+"/bad").UsedAddresses([]string{addr1})
+	assert.EqualError(t, err, "get transactions failed, status: 404 Not Found")
+
+	tmpDir := filepath.Join(os.TempDir(), ".wallet1001")
+	defer os.RemoveAll(tmpDir)
+	err = Init(tmpDir, "")
+	assert.NoError(t, err)
+	mnemonic := strings.Repeat("abandon ", 11) + "about"
+	_, err = RestoreWallet("spo", "l1", mnemonic, "", "12345678", 0)
+	assert.EqualError(t, err, "spo is not supported")
+	assert.NoError(t, RegisterNewCoin("spo", nodeAddr))
+
+	// the wallet keeps the addresses up to the used one.
+	wlt, err := NewWallet("spo", "l1", mnemonic, "12345678")
+	assert.NoError(t, err)
+	_, err = NewAddress(wlt, 4, "12345678")
+	assert.NoError(t, err)
+	addrs, err := walletex.GetAddresses(wlt)
+	assert.NoError(t, err)
+	assert.NoError(t, Remove(wlt))
+	used = addrs[3]
+
+	_, err = RestoreWallet("spo", "l1", mnemonic, "", "", 0)
+	assert.EqualError(t, err, "password cannot empty")
+	id, err := RestoreWallet("spo", "l1", mnemonic, "", "12345678", 5)
+	assert.NoError(t, err)
+	restored, err := walletex.GetAddresses(id)
+	assert.NoError(t, err)
+	assert.Equal(t, addrs[:4], restored)
+
+	// the used flag and label are kept in the wallet.
+	assert.NoError(t, RefreshAddressUsage("spo", id))
+	assert.NoError(t, SetAddressLabel(id, addrs[3], "From Alice"))
+	info, err := GetAddressesInfo(id)
+	assert.NoError(t, err)
+	var res struct {
+		Addresses []struct {
+			Address string `json:"address"`
+			Label   string `json:"label"`
+			Index   int    `json:"index"`
+			Used    bool   `json:"used"`
+		} `json:"addresses"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(info), &res))
+	assert.Len(t, res.Addresses, 4)
+	for i, a := range res.Addresses {
+		assert.Equal(t, addrs[i], a.Address)
+		assert.Equal(t, i, a.Index)
+		assert.Equal(t, i == 3, a.Used)
+	}
+	assert.Equal(t, "From Alice", res.Addresses[3].Label)
+	assert.NoError(t, SetAddressUsed(id, addrs[3], false))
+	assert.EqualError(t, SetAddressUsed(id, "badaddr", true), "badaddr addr does not exist in wallet")
+}
+
+func TestChangeAddr(t *testing.T) {
+	tmpDir := filepath.Join(os.TempDir(), ".wallet1002")
+	defer os.RemoveAll(tmpDir)
+	err := Init(tmpDir, "")
+	assert.NoError(t, err)
+
+	passwd := "12345678"
+	wlt, err := NewWallet("spo", "l1", strings.Repeat("abandon ", 11)+"about", passwd)
+	assert.NoError(t, err)
+	addrs, err := walletex.GetAddresses(wlt)
+	assert.NoError(t, err)
+	pub, _ := cipher.GenerateDeterministicKeyPair([]byte("change"))
+	fixed := cipher.AddressFromPubKey(pub)
+	uxBal := []wallet.UxBalance{{Address: cipher.MustDecodeBase58Address(addrs[0])}}
+
+	// the first input address is the default.
+	addr, err := changeAddr(sendParams{WalletID: wlt}, uxBal)
+	assert.NoError(t, err)
+	assert.Equal(t, addrs[0], addr)
+	addr, err = changeAddr(sendParams{WalletID: wlt, ChangePolicy: ChangeFirstInput}, uxBal)
+	assert.NoError(t, err)
+	assert.Equal(t, addrs[0], addr)
+	_, err = changeAddr(sendParams{WalletID: wlt, ChangePolicy: ChangeFirstInput}, nil)
+	assert.Equal(t, ErrNoChangeAddr, err)
+
+	addr, err = changeAddr(sendParams{WalletID: wlt, ChangePolicy: ChangeFixed, ChangeAddr: fixed.String()}, uxBal)
+	assert.NoError(t, err)
+	assert.Equal(t, fixed.String(), addr)
+
+	_, err = changeAddr(sendParams{WalletID: wlt, Passwd: "87654321", ChangePolicy: ChangeFresh}, uxBal)
+	assert.EqualError(t, err, "derive change address failed: wallet password incorrect")
+	addr, err = changeAddr(sendParams{WalletID: wlt, Passwd: passwd, ChangePolicy: ChangeFresh}, uxBal)
+	assert.NoError(t, err)
+	assert.NotEqual(t, addrs[0], addr)
+
+	// the fresh change address is saved before the transaction is made.
+	walletex.Reset()
+	err = LoadWallet(passwd)
+	assert.NoError(t, err)
+	ok, err := walletex.IsContain(wlt, []string{addr})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	cn := newCoin("spo", "")
+	_, _, err = cn.PrepareTx(sendParams{WalletID: wlt, ToAddr: fixed.String(), Amount: 1e6, ChangePolicy: "reuse"})
+	assert.EqualError(t, err, "unknown change policy reuse")
+	_, _, err = cn.PrepareTx(sendParams{WalletID: wlt, ToAddr: fixed.String(), Amount: 1e6, ChangePolicy: ChangeFixed, ChangeAddr: "bad"})
+	assert.EqualError(t, err, "invalid change address bad")
+}
+
+func TestSweep(t *testing.T) {
+	var queried string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queried = r.URL.Query().Get("addrs")
+		w.Write([]byte(`{"head_outputs":[],"outgoing_outputs":[],"incoming_outputs":[]}`))
+	}))
+	defer srv.Close()
+	nodeAddr := strings.TrimPrefix(srv.URL, "http://")
+
+	tmpDir := filepath.Join(os.TempDir(), ".wallet1006")
+	defer os.RemoveAll(tmpDir)
+	err := Init(tmpDir, "")
+	assert.NoError(t, err)
+	assert.NoError(t, RegisterNewCoin("spo", nodeAddr))
+	wlt, err := NewWallet("spo", "l1", "", "12345678")
+	assert.NoError(t, err)
+
+	res, err := NewPaperWallet("spo", false)
+	assert.NoError(t, err)
+	var pw struct {
+		Address string `json:"address"`
+		Seckey  string `json:"seckey"`
+		SVG     string `json:"svg"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(res), &pw))
+	assert.Contains(t, pw.SVG, pw.Address)
+
+	_, err = Sweep("btc", pw.Seckey, wlt)
+	assert.EqualError(t, err, "btc is not supported")
+	_, err = Sweep("spo", pw.Seckey, "skycoin_xxx")
+	assert.EqualError(t, err, "invalid wallet skycoin")
+	_, err = Sweep("spo", "seed1", wlt)
+	assert.EqualError(t, err, "secret must be hex secret key or bip39 mnemonic")
+	_, err = Sweep("spo", pw.Seckey, wlt)
+	assert.EqualError(t, err, "no coins to sweep")
+	assert.Equal(t, pw.Address, queried)
+
+	// the paper wallet of seed is swept by the first address of the seed.
+	res, err = NewPaperWallet("spo", true)
+	assert.NoError(t, err)
+	var sw struct {
+		Address string `json:"address"`
+		Seed    string `json:"seed"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(res), &sw))
+	_, err = Sweep("spo", sw.Seed, wlt)
+	assert.EqualError(t, err, "no coins to sweep")
+	assert.Equal(t, sw.Address, queried)
+
+	_, err = NewPaperWallet("btc", false)
+	assert.EqualError(t, err, "btc wallet not regestered")
+}
@@ -0,0 +1,40 @@
+package aynrandcoin
+
+import (
+	skycoin "github.com/MDLlife/wallet-api/src/coin/skycoin"
+	"github.com/MDLlife/wallet-api/src/wallet"
+)
+
+// Type represents aynrandcoin coin type
+var Type = "aynrandcoin"
+
+func init() {
+	// Register wallet creator
+	wallet.RegisterCreator(Type, func() wallet.Walleter {
+		return &skycoin.Wallet{
+			Wallet: wallet.Wallet{
+				Type: Type,
+			},
+		}
+	})
+}
+
+// Aynrandcoin will implement coin.Gateway interface
+type Aynrandcoin struct {
+	skycoin.Skycoin // embeded from skycoin , as all apis are the same as skycoin
+}
+
+// New creates a aynrandcoin instance.
+func New(nodeAddr string) *Aynrandcoin {
+	return &Aynrandcoin{Skycoin: skycoin.Skycoin{NodeAddress: nodeAddr}}
+}
+
+// Symbol returns the aynrandcoin symbol
+func (arc Aynrandcoin) Symbol() string {
+	return "ARC"
+}
+
+// Type returns aynrandcoin type
+func (arc Aynrandcoin) Type() string {
+	return Type
+}
@@ -7,12 +7,18 @@ type TxIn struct {
 	Vout    uint32
 }
 
-// GetPrivKey is a callback func used for SignTx func to get relevant private key of specific address.
-type GetPrivKey func(addr string) (string, error)
+// GetPrivKey is a callback func used for SignTx func to get relevant private key of specific address,
+// the key is returned as raw bytes which the caller wipes after signing.
+type GetPrivKey func(addr string) ([]byte, error)
 
-// AddressEntry represents the wallet address
+// AddressEntry represents the wallet address, Label, Index, Created and Used are the
+// metadata of the address kept in the wallet file.
 type AddressEntry struct {
 	Address string `json:"address"`
 	Public  string `json:"pubkey"`
 	Secret  string `json:"seckey"`
+	Label   string `json:"label,omitempty"` // label set by user, such as "Savings".
+	Index   int    `json:"index"`           // derivation index, -1 for imported or watched address.
+	Created int64  `json:"created"`         // unix time when the address was added.
+	Used    bool   `json:"used"`            // the address has received coins when last checked.
 }
@@ -0,0 +1,40 @@
+package mzcoin
+
+import (
+	skycoin "github.com/MDLlife/wallet-api/src/coin/skycoin"
+	"github.com/MDLlife/wallet-api/src/wallet"
+)
+
+// Type represents mzcoin coin type
+var Type = "mzcoin"
+
+func init() {
+	// Register wallet creator
+	wallet.RegisterCreator(Type, func() wallet.Walleter {
+		return &skycoin.Wallet{
+			Wallet: wallet.Wallet{
+				Type: Type,
+			},
+		}
+	})
+}
+
+// MZcoin will implement coin.Gateway interface
+type MZcoin struct {
+	skycoin.Skycoin // embeded from skycoin , as all apis are the same as skycoin
+}
+
+// New creates a mzcoin instance.
+func New(nodeAddr string) *MZcoin {
+	return &MZcoin{Skycoin: skycoin.Skycoin{NodeAddress: nodeAddr}}
+}
+
+// Symbol returns the mzcoin symbol
+func (mz MZcoin) Symbol() string {
+	return "MZC"
+}
+
+// Type returns mzcoin type
+func (mz MZcoin) Type() string {
+	return Type
+}
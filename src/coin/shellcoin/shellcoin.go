@@ -0,0 +1,40 @@
+package shellcoin
+
+import (
+	skycoin "github.com/MDLlife/wallet-api/src/coin/skycoin"
+	"github.com/MDLlife/wallet-api/src/wallet"
+)
+
+// Type represents shellcoin coin type
+var Type = "shellcoin"
+
+func init() {
+	// Register wallet creator
+	wallet.RegisterCreator(Type, func() wallet.Walleter {
+		return &skycoin.Wallet{
+			Wallet: wallet.Wallet{
+				Type: Type,
+			},
+		}
+	})
+}
+
+// Shellcoin will implement coin.Gateway interface
+type Shellcoin struct {
+	skycoin.Skycoin // embeded from skycoin , as all apis are the same as skycoin
+}
+
+// New creates a shellcoin instance.
+func New(nodeAddr string) *Shellcoin {
+	return &Shellcoin{Skycoin: skycoin.Skycoin{NodeAddress: nodeAddr}}
+}
+
+// Symbol returns the shellcoin symbol
+func (shl Shellcoin) Symbol() string {
+	return "SHL"
+}
+
+// Type returns shellcoin type
+func (shl Shellcoin) Type() string {
+	return Type
+}
@@ -1,13 +1,14 @@
 package skycoin
 
 import (
-	"fmt"
+	"encoding/hex"
 
 	logging "github.com/op/go-logging"
 	"github.com/skycoin/skycoin/src/cipher"
 	sky "github.com/skycoin/skycoin/src/coin"
 	"github.com/skycoin/skycoin/src/visor"
 	"github.com/MDLlife/wallet-api/src/coin"
+	"github.com/MDLlife/wallet-api/src/util/secure"
 )
 
 var (
@@ -37,17 +38,24 @@ type TxOut struct {
 	sky.TransactionOutput
 }
 
-// GenerateAddresses generate addresses.
-func GenerateAddresses(seed []byte, num int) (string, []coin.AddressEntry) {
+// GenerateAddresses generate addresses, returns the next seed in hex, the entries
+// and their secret keys. The seed and secret keys are in buffers allocated by
+// secure.Bytes, the caller wipes them after use.
+func GenerateAddresses(seed []byte, num int) ([]byte, []coin.AddressEntry, [][]byte) {
 	sd, seckeys := cipher.GenerateDeterministicKeyPairsSeed(seed, num)
 	entries := make([]coin.AddressEntry, num)
-	for i, sec := range seckeys {
-		pub := cipher.PubKeyFromSecKey(sec)
+	secrets := make([][]byte, num)
+	for i := range seckeys {
+		pub := cipher.PubKeyFromSecKey(seckeys[i])
 		entries[i].Address = cipher.AddressFromPubKey(pub).String()
 		entries[i].Public = pub.Hex()
-		entries[i].Secret = sec.Hex()
+		secrets[i] = secure.Copy(seckeys[i][:])
+		secure.Wipe(seckeys[i][:])
 	}
-	return fmt.Sprintf("%2x", sd), entries
+	next := secure.Bytes(hex.EncodedLen(len(sd)))
+	hex.Encode(next, sd)
+	secure.Wipe(sd)
+	return next, entries, secrets
 }
 
 // Symbol returns skycoin sybmol
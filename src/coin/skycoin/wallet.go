@@ -1,10 +1,15 @@
 package skycoin
 
 import (
+	"bytes"
 	"encoding/hex"
+	"errors"
+	"fmt"
 
 	"github.com/MDLlife/wallet-api/src/coin"
+	"github.com/MDLlife/wallet-api/src/util/secure"
 	"github.com/MDLlife/wallet-api/src/wallet"
+	"github.com/skycoin/skycoin/src/cipher"
 )
 
 // Wallet skycoin wallet struct
@@ -14,20 +19,58 @@ type Wallet struct {
 
 // NewAddresses generate skycoin addresses.
 func (wlt *Wallet) NewAddresses(num int) ([]coin.AddressEntry, error) {
-	entries := []coin.AddressEntry{}
-	if wlt.Seed == wlt.InitSeed {
-		wlt.Seed, entries = GenerateAddresses([]byte(wlt.Seed), num)
-		wlt.AddressEntries = append(wlt.AddressEntries, entries...)
-		return entries, nil
+	if wlt.WalletType == wallet.CollectionWalletType {
+		return nil, errors.New("collection wallet has no seed, import keys instead")
 	}
+	if bytes.Equal(wlt.Seed, wlt.InitSeed) {
+		seed, entries, secrets := GenerateAddresses(wlt.Seed, num)
+		return entries, wlt.setSeed(seed, entries, secrets)
+	}
+
+	s := secure.Bytes(hex.DecodedLen(len(wlt.Seed)))
+	defer secure.Wipe(s)
+	if _, err := hex.Decode(s, wlt.Seed); err != nil {
+		return nil, err
+	}
+	seed, entries, secrets := GenerateAddresses(s, num)
+	return entries, wlt.setSeed(seed, entries, secrets)
+}
 
-	s, err := hex.DecodeString(wlt.Seed)
-	if err != nil {
-		return entries, err
+// setSeed replaces the seed by the next one, and adds the new entries.
+func (wlt *Wallet) setSeed(seed []byte, entries []coin.AddressEntry, secrets [][]byte) error {
+	secure.Wipe(wlt.Seed)
+	wlt.Seed = seed
+	return wlt.AddEntries(entries, secrets)
+}
+
+// ImportKey import the secret key into collection wallet, keys of deterministic
+// wallet are derived from the seed only, so the wallet can be recovered by the seed.
+func (wlt *Wallet) ImportKey(seckey []byte) (coin.AddressEntry, error) {
+	if wlt.WalletType != wallet.CollectionWalletType {
+		return coin.AddressEntry{}, errors.New("keys can only be imported into collection wallet")
+	}
+
+	var sec cipher.SecKey
+	if len(seckey) != len(sec) {
+		return coin.AddressEntry{}, errors.New("invalid secret key")
+	}
+	copy(sec[:], seckey)
+	defer secure.Wipe(sec[:])
+	if err := sec.Verify(); err != nil {
+		return coin.AddressEntry{}, errors.New("invalid secret key")
+	}
+
+	pub := cipher.PubKeyFromSecKey(sec)
+	entry := coin.AddressEntry{
+		Address: cipher.AddressFromPubKey(pub).String(),
+		Public:  pub.Hex(),
+	}
+	for _, e := range wlt.AddressEntries {
+		if e.Address == entry.Address {
+			return coin.AddressEntry{}, fmt.Errorf("%s already exist", entry.Address)
+		}
 	}
-	wlt.Seed, entries = GenerateAddresses(s, num)
-	wlt.AddressEntries = append(wlt.AddressEntries, entries...)
-	return entries, nil
+	return entry, wlt.AddEntries([]coin.AddressEntry{entry}, [][]byte{secure.Copy(seckey)})
 }
 
 // Copy returns copy of self
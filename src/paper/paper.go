@@ -0,0 +1,190 @@
+// Package paper generates printable paper wallets, an address with its secret key or seed
+// rendered as a self-contained SVG page with QR codes, for gift wallets funded in advance.
+package paper
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/MDLlife/wallet-api/src/coin"
+	"github.com/MDLlife/wallet-api/src/coin/skycoin"
+	"github.com/MDLlife/wallet-api/src/util/secure"
+	"github.com/MDLlife/wallet-api/src/wallet"
+	qrcode "github.com/skip2/go-qrcode"
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+// Wallet is the paper wallet, which has either the secret key or the seed of the address.
+type Wallet struct {
+	CoinType string `json:"coin_type"`
+	Address  string `json:"address"`
+	Seckey   string `json:"seckey,omitempty"` // hex secret key of the address.
+	Seed     string `json:"seed,omitempty"`   // bip39 mnemonic, the address is the first one of the seed.
+}
+
+// New generates a paper wallet of random secret key.
+func New(coinType string) (Wallet, error) {
+	if err := checkCoinType(coinType); err != nil {
+		return Wallet{}, err
+	}
+	seed := secure.Bytes(32)
+	defer secure.Wipe(seed)
+	if _, err := rand.Read(seed); err != nil {
+		return Wallet{}, err
+	}
+	next, entries, secrets := skycoin.GenerateAddresses(seed, 1)
+	secure.Wipe(next)
+	defer secure.Wipe(secrets[0])
+	return Wallet{
+		CoinType: coinType,
+		Address:  entries[0].Address,
+		Seckey:   hex.EncodeToString(secrets[0]),
+	}, nil
+}
+
+// NewWithSeed generates a paper wallet of fresh bip39 mnemonic, the seed restores the same
+// address by wallet.New.
+func NewWithSeed(coinType string) (Wallet, error) {
+	if err := checkCoinType(coinType); err != nil {
+		return Wallet{}, err
+	}
+	seed := wallet.NewSeed()
+	entry, sec := seedKey(seed)
+	secure.Wipe(sec)
+	return Wallet{
+		CoinType: coinType,
+		Address:  entry.Address,
+		Seed:     seed,
+	}, nil
+}
+
+func checkCoinType(coinType string) error {
+	for _, tp := range wallet.CoinTypes() {
+		if tp == coinType {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s wallet not regestered", coinType)
+}
+
+// seedKey returns the first address of the seed and its secret key, the caller wipes the key.
+func seedKey(seed string) (coin.AddressEntry, []byte) {
+	s := secure.FromString(seed)
+	defer secure.Wipe(s)
+	next, entries, secrets := skycoin.GenerateAddresses(s, 1)
+	secure.Wipe(next)
+	return entries[0], secrets[0]
+}
+
+// ParseSecret returns the address and its secret key of the secret printed on the paper wallet,
+// which is either the hex secret key or the bip39 mnemonic. The caller wipes the key.
+func ParseSecret(secret string) (coin.AddressEntry, []byte, error) {
+	secret = strings.TrimSpace(secret)
+	var sec cipher.SecKey
+	if len(secret) == hex.EncodedLen(len(sec)) {
+		if _, err := hex.Decode(sec[:], []byte(secret)); err == nil {
+			defer secure.Wipe(sec[:])
+			if err := sec.Verify(); err != nil {
+				return coin.AddressEntry{}, nil, errors.New("invalid secret key")
+			}
+			pub := cipher.PubKeyFromSecKey(sec)
+			entry := coin.AddressEntry{
+				Address: cipher.AddressFromPubKey(pub).String(),
+				Public:  pub.Hex(),
+			}
+			return entry, secure.Copy(sec[:]), nil
+		}
+	}
+	// the mnemonic is normalized as wallet.New does, so it derives the keys of the restored wallet.
+	seed, err := wallet.NormalizeMnemonic(secret)
+	if err != nil {
+		return coin.AddressEntry{}, nil, errors.New("secret must be hex secret key or bip39 mnemonic")
+	}
+	entry, key := seedKey(seed)
+	return entry, key, nil
+}
+
+// SVG renders the paper wallet as a A4 page, the address QR code is for funding
+// and the secret QR code is for sweeping.
+func (w Wallet) SVG() (string, error) {
+	secret, title := w.Seckey, "Secret key"
+	if w.Seed != "" {
+		secret, title = w.Seed, "Seed"
+	}
+	if w.Address == "" || secret == "" {
+		return "", errors.New("paper wallet has no address or secret")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	buf.WriteString(`<svg xmlns="http://www.w3.org/2000/svg" width="210mm" height="297mm" viewBox="0 0 210 297">` + "\n")
+	buf.WriteString(`<rect width="210" height="297" fill="#fff"/>` + "\n")
+	fmt.Fprintf(&buf, `<text x="105" y="25" font-family="sans-serif" font-size="9" text-anchor="middle">%s paper wallet</text>`+"\n",
+		html.EscapeString(strings.ToUpper(w.CoinType)))
+
+	if err := writeQR(&buf, w.Address, 15, 40, 80); err != nil {
+		return "", err
+	}
+	writeText(&buf, 55, 130, "Address", []string{w.Address})
+
+	if err := writeQR(&buf, secret, 115, 40, 80); err != nil {
+		return "", err
+	}
+	writeText(&buf, 155, 130, title, splitLines(secret))
+
+	buf.WriteString(`<text x="105" y="200" font-family="sans-serif" font-size="4" text-anchor="middle">` +
+		`Keep the secret private, anyone who has it can spend the coins.</text>` + "\n")
+	buf.WriteString("</svg>\n")
+	return buf.String(), nil
+}
+
+// writeQR draws the QR code of content in the size x size square at x, y.
+func writeQR(buf *bytes.Buffer, content string, x, y, size float64) error {
+	q, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return err
+	}
+	bm := q.Bitmap()
+	fmt.Fprintf(buf, `<g transform="translate(%g %g) scale(%g)"><path fill="#000" d="`, x, y, size/float64(len(bm)))
+	for r, row := range bm {
+		for c, dark := range row {
+			if dark {
+				fmt.Fprintf(buf, "M%d %dh1v1h-1z", c, r)
+			}
+		}
+	}
+	buf.WriteString(`"/></g>` + "\n")
+	return nil
+}
+
+func writeText(buf *bytes.Buffer, x, y float64, title string, lines []string) {
+	fmt.Fprintf(buf, `<text x="%g" y="%g" font-family="sans-serif" font-size="5" text-anchor="middle">%s</text>`+"\n",
+		x, y, html.EscapeString(title))
+	for i, l := range lines {
+		fmt.Fprintf(buf, `<text x="%g" y="%g" font-family="monospace" font-size="3" text-anchor="middle">%s</text>`+"\n",
+			x, y+8+float64(i)*5, html.EscapeString(l))
+	}
+}
+
+// splitLines splits the mnemonic into lines of 6 words, the hex key into 2 lines.
+func splitLines(secret string) []string {
+	words := strings.Fields(secret)
+	if len(words) == 1 {
+		n := (len(secret) + 1) / 2
+		return []string{secret[:n], secret[n:]}
+	}
+	var lines []string
+	for i := 0; i < len(words); i += 6 {
+		end := i + 6
+		if end > len(words) {
+			end = len(words)
+		}
+		lines = append(lines, strings.Join(words[i:end], " "))
+	}
+	return lines
+}
@@ -0,0 +1,132 @@
+package paper
+
+import (
+	"encoding/hex"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	_ "github.com/MDLlife/wallet-api/src/coin/skycoin"
+	_ "github.com/MDLlife/wallet-api/src/coin/spo"
+	"github.com/MDLlife/wallet-api/src/wallet"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/unicode/norm"
+)
+
+func TestNew(t *testing.T) {
+	dir, err := ioutil.TempDir("", "paper")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	wallet.InitDir(dir)
+	wallet.Reset()
+
+	for _, tp := range wallet.CoinTypes() {
+		pw, err := New(tp)
+		assert.NoError(t, err, tp)
+		assert.Equal(t, tp, pw.CoinType)
+		assert.Empty(t, pw.Seed)
+		entry, sec, err := ParseSecret(pw.Seckey)
+		assert.NoError(t, err, tp)
+		assert.Equal(t, pw.Address, entry.Address)
+		assert.Equal(t, pw.Seckey, hex.EncodeToString(sec))
+
+		// the key is swept by the collection wallet as well.
+		cwlt, err := wallet.NewCollection(tp, "c1", "12345678")
+		assert.NoError(t, err, tp)
+		addr, err := wallet.ImportKey(cwlt.GetID(), pw.Seckey, "12345678")
+		assert.NoError(t, err, tp)
+		assert.Equal(t, pw.Address, addr)
+
+		// the seed restores the same address.
+		pw, err = NewWithSeed(tp)
+		assert.NoError(t, err, tp)
+		assert.Empty(t, pw.Seckey)
+		assert.NoError(t, wallet.ValidateMnemonic(pw.Seed))
+		wlt, err := wallet.New(tp, "l1", pw.Seed, "12345678")
+		assert.NoError(t, err, tp)
+		addrs, err := wallet.GetAddresses(wlt.GetID())
+		assert.NoError(t, err, tp)
+		assert.Equal(t, []string{pw.Address}, addrs)
+		entry, _, err = ParseSecret(" " + strings.Replace(pw.Seed, " ", "\n", 1))
+		assert.NoError(t, err, tp)
+		assert.Equal(t, pw.Address, entry.Address)
+	}
+
+	_, err = New("btc")
+	assert.EqualError(t, err, "btc wallet not regestered")
+	_, err = NewWithSeed(wallet.WatchType)
+	assert.EqualError(t, err, "watch wallet not regestered")
+}
+
+func TestParseSecret(t *testing.T) {
+	_, _, err := ParseSecret(strings.Repeat("0", 64))
+	assert.EqualError(t, err, "invalid secret key")
+	_, _, err = ParseSecret("seed1")
+	assert.EqualError(t, err, "secret must be hex secret key or bip39 mnemonic")
+	_, _, err = ParseSecret(strings.Repeat("abandon ", 12))
+	assert.EqualError(t, err, "secret must be hex secret key or bip39 mnemonic")
+}
+
+func TestParseComposedSecret(t *testing.T) {
+	dir, err := ioutil.TempDir("", "paper")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	wallet.InitDir(dir)
+	wallet.Reset()
+
+	// the mnemonic typed with composed accents sweeps the address of the restored wallet.
+	var spanish string
+	for i := 0; i < 100 && norm.NFC.String(spanish) == norm.NFKD.String(spanish); i++ {
+		spanish, err = wallet.NewSeedWithOptions(128, wallet.LangSpanish)
+		assert.NoError(t, err)
+	}
+	composed := norm.NFC.String(spanish)
+	assert.NotEqual(t, norm.NFKD.String(spanish), composed)
+	wlt, err := wallet.New("spo", "l1", composed, "12345678")
+	assert.NoError(t, err)
+	addrs, err := wallet.GetAddresses(wlt.GetID())
+	assert.NoError(t, err)
+	entry, sec, err := ParseSecret(composed)
+	assert.NoError(t, err)
+	assert.Equal(t, addrs[0], entry.Address)
+	key, err := wallet.GetSeckey(wlt.GetID(), addrs[0], "12345678")
+	assert.NoError(t, err)
+	assert.Equal(t, key, sec)
+}
+
+func TestSVG(t *testing.T) {
+	for _, pw := range []Wallet{
+		{CoinType: "spo", Address: "2GgFvqoyk9RjwVzj8tqfcXVXB4orBwoc9qv", Seckey: strings.Repeat("a0", 32)},
+		{CoinType: "skycoin", Address: "2GgFvqoyk9RjwVzj8tqfcXVXB4orBwoc9qv", Seed: strings.Repeat("abandon ", 11) + "about"},
+	} {
+		svg, err := pw.SVG()
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(svg, `<?xml version="1.0" encoding="UTF-8"?>`))
+		assert.Contains(t, svg, strings.ToUpper(pw.CoinType)+" paper wallet")
+		assert.Contains(t, svg, pw.Address)
+		for _, l := range splitLines(pw.Seckey + pw.Seed) {
+			assert.Contains(t, svg, ">"+l+"<")
+		}
+		assert.Equal(t, 2, strings.Count(svg, "<path "))
+
+		// the page is well-formed and self-contained.
+		assert.NotContains(t, svg, "href")
+		dec := xml.NewDecoder(strings.NewReader(svg))
+		for {
+			_, err := dec.Token()
+			if err == io.EOF {
+				break
+			}
+			assert.NoError(t, err)
+			if err != nil {
+				break
+			}
+		}
+	}
+
+	_, err := Wallet{CoinType: "spo", Address: "2GgFvqoyk9RjwVzj8tqfcXVXB4orBwoc9qv"}.SVG()
+	assert.EqualError(t, err, "paper wallet has no address or secret")
+}
@@ -0,0 +1,40 @@
+package encrypt
+
+import (
+	"fmt"
+)
+
+const (
+	// CipherArgon2idChacha20poly1305 derives the key with argon2id and encrypts with chacha20poly1305
+	CipherArgon2idChacha20poly1305 = "argon2id-chacha20poly1305"
+
+	// KDFArgon2id argon2id key derivation function
+	KDFArgon2id = "argon2id"
+
+	// maxArgon2idMemory avoid exhausting memory by the parameters read from file, 1 GiB
+	maxArgon2idMemory = 1 << 20
+
+	// maxArgon2idTime avoid exhausting cpu by the parameters read from file
+	maxArgon2idTime = 16
+)
+
+// DefaultArgon2idOptions default argon2id options, 64 MiB memory with 3 passes.
+var DefaultArgon2idOptions = Options{
+	Cipher:  CipherArgon2idChacha20poly1305,
+	Time:    3,
+	Memory:  64 * 1024,
+	Threads: 4,
+}
+
+func validateArgon2id(time, memory uint32, threads uint8) error {
+	if time == 0 || time > maxArgon2idTime {
+		return fmt.Errorf("invalid argon2id time %d", time)
+	}
+	if threads == 0 {
+		return fmt.Errorf("invalid argon2id threads %d", threads)
+	}
+	if memory < 8*uint32(threads) || memory > maxArgon2idMemory {
+		return fmt.Errorf("invalid argon2id memory %d", memory)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package encrypt
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeriveKeyKnownAnswer(t *testing.T) {
+	salt := hex.EncodeToString([]byte("somesalt"))
+	testData := []struct {
+		Params   Params
+		Password string
+		Key      string
+	}{
+		// argon2id vectors generated by the reference implementation.
+		{
+			Params:   Params{KDF: KDFArgon2id, Time: 1, Memory: 64, Threads: 1, KeyLen: 24, Salt: salt},
+			Password: "password",
+			Key:      "655ad15eac652dc59f7170a7332bf49b8469be1fdb9c28bb",
+		},
+		{
+			Params:   Params{KDF: KDFArgon2id, Time: 2, Memory: 64, Threads: 2, KeyLen: 24, Salt: salt},
+			Password: "password",
+			Key:      "350ac37222f436ccb5c0972f1ebd3bf6b958bf2071841362",
+		},
+		{
+			Params:   Params{KDF: KDFArgon2id, Time: 3, Memory: 256, Threads: 2, KeyLen: 24, Salt: salt},
+			Password: "password",
+			Key:      "4668d30ac4187e6878eedeacf0fd83c5a0a30db2cc16ef0b",
+		},
+		// scrypt vectors of RFC 7914.
+		{
+			Params:   Params{KDF: KDFScrypt, N: 16, R: 1, P: 1, KeyLen: 64},
+			Password: "",
+			Key:      "77d6576238657b203b19ca42c18a0497f16b4844e3074ae8dfdffa3fede21442fcd0069ded0948f8326a753a0fc81f17e8d3e0fb2e0d3628cf35e20c38d18906",
+		},
+		{
+			Params:   Params{KDF: KDFScrypt, N: 1024, R: 8, P: 16, KeyLen: 64, Salt: hex.EncodeToString([]byte("NaCl"))},
+			Password: "password",
+			Key:      "fdbabe1c9d3472007856e7190d01e9fe7c6ad7cbc8237830e77376634b3731622eaf30d92e22a3886ff109279d9830dac727afb94a83ee6d8360cbdfa2cc0640",
+		},
+	}
+
+	for _, d := range testData {
+		key, err := d.Params.deriveKey([]byte(d.Password))
+		assert.NoError(t, err)
+		assert.Equal(t, d.Key, hex.EncodeToString(key))
+	}
+}
+
+func TestSealKnownAnswer(t *testing.T) {
+	nonce, _ := hex.DecodeString("000102030405060708090a0b")
+	params := &Params{
+		Cipher:  CipherArgon2idChacha20poly1305,
+		KDF:     KDFArgon2id,
+		KeyLen:  32,
+		Salt:    hex.EncodeToString([]byte("somesalt")),
+		Time:    1,
+		Memory:  64,
+		Threads: 1,
+	}
+	expect := "AAECAwQFBgcICQoLRO4S79RB1KA+8N2n8zl1o2/C09F703OWtlBc"
+
+	key, err := DeriveKey([]byte("password"), params)
+	assert.NoError(t, err)
+	defer key.Wipe()
+	encry, err := key.seal([]byte("secret text"), nonce)
+	assert.NoError(t, err)
+	assert.Equal(t, expect, encry)
+
+	decry, err := Decrypt([]byte("password"), expect, params)
+	assert.NoError(t, err)
+	assert.Equal(t, "secret text", decry)
+}
+
+func TestArgon2idEncrypt(t *testing.T) {
+	key := []byte("12345678")
+	text := "secret text"
+
+	opts := Options{Cipher: CipherArgon2idChacha20poly1305, Time: 1, Memory: 1024, Threads: 1}
+	encry, params, err := Encrypt(key, text, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, KDFArgon2id, params.KDF)
+	assert.Equal(t, 0, params.N)
+	assert.True(t, params.Match(opts))
+	assert.False(t, params.Match(DefaultScryptOptions))
+
+	decry, err := Decrypt(key, encry, params)
+	assert.NoError(t, err)
+	assert.Equal(t, text, decry)
+
+	_, err = Decrypt([]byte("87654321"), encry, params)
+	assert.EqualError(t, err, "invalid password")
+
+	// kdf must be the one of the cipher
+	wrong := *params
+	wrong.KDF = KDFScrypt
+	_, err = Decrypt(key, encry, &wrong)
+	assert.Error(t, err)
+
+	assert.Error(t, Options{Cipher: CipherArgon2idChacha20poly1305, Memory: maxArgon2idMemory + 1}.Validate())
+	assert.Equal(t, DefaultArgon2idOptions, Options{Cipher: CipherArgon2idChacha20poly1305, N: 1024}.withDefault())
+}
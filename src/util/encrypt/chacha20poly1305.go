@@ -1,9 +1,77 @@
 package encrypt
 
 import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/MDLlife/wallet-api/src/util/secure"
 	"github.com/skycoin/skycoin/src/cipher/encrypt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
 )
 
+const (
+	// CipherScryptChacha20poly1305 derives the key with scrypt and encrypts with chacha20poly1305
+	CipherScryptChacha20poly1305 = "scrypt-chacha20poly1305"
+
+	// KDFScrypt scrypt key derivation function
+	KDFScrypt = "scrypt"
+
+	saltLen = 32
+	// maxScryptN, maxScryptR and maxScryptP avoid exhausting memory and cpu by the
+	// parameters read from file, scrypt takes 128 * N * R bytes, 1 GiB at most.
+	maxScryptN      = 1 << 20
+	maxScryptR      = 32
+	maxScryptP      = 16
+	maxScryptMemory = 1 << 30
+	// maxKeyLen the longest key derived by the kdf
+	maxKeyLen = 64
+)
+
+// Options encryption options, zero fields are set by the default options of the cipher.
+type Options struct {
+	Cipher  string // cipher id
+	N       int    // scrypt cpu/memory cost, must be power of 2
+	R       int    // scrypt block size
+	P       int    // scrypt parallelization
+	Time    uint32 // argon2id number of passes
+	Memory  uint32 // argon2id memory in KiB
+	Threads uint8  // argon2id parallelism
+}
+
+// DefaultScryptOptions default scrypt options,
+// encryption speed is very slow on mobile if using bigger N.
+var DefaultScryptOptions = Options{
+	Cipher: CipherScryptChacha20poly1305,
+	N:      1 << 16,
+	R:      8,
+	P:      1,
+}
+
+// DefaultOptions default encryption options.
+var DefaultOptions = DefaultScryptOptions
+
+// Params records the cipher and kdf parameters which produced the encrypted text.
+type Params struct {
+	Cipher string `json:"cipher"`
+	KDF    string `json:"kdf"`
+	N      int    `json:"n"`
+	R      int    `json:"r"`
+	P      int    `json:"p"`
+	KeyLen int    `json:"key_len"`
+	Salt   string `json:"salt"` // hex encoded salt
+
+	Time    uint32 `json:"time,omitempty"`
+	Memory  uint32 `json:"memory,omitempty"`
+	Threads uint8  `json:"threads,omitempty"`
+}
+
+// glosha decrypts the text encrypted before the parameters were recorded.
 //var glosha encrypt.Sha256Xor
 var glosha encrypt.ScryptChacha20poly1305
 
@@ -13,20 +81,313 @@ func init() {
 	glosha.N = 1 << 16
 }
 
-//Encrypt encrypt text
-func Encrypt(key []byte, text string) (string, error) {
-	encry, err := glosha.Encrypt([]byte(text), key)
+// Validate check the options are supported.
+func (opts Options) Validate() error {
+	opts = opts.withDefault()
+	switch opts.Cipher {
+	case CipherScryptChacha20poly1305:
+		return validateScrypt(opts.N, opts.R, opts.P)
+	case CipherArgon2idChacha20poly1305:
+		return validateArgon2id(opts.Time, opts.Memory, opts.Threads)
+	default:
+		return fmt.Errorf("cipher %s not supported", opts.Cipher)
+	}
+}
+
+func validateScrypt(n, r, p int) error {
+	if n <= 1 || n&(n-1) != 0 || n > maxScryptN {
+		return fmt.Errorf("invalid scrypt N %d", n)
+	}
+	if r <= 0 || r > maxScryptR || p <= 0 || p > maxScryptP || scryptMemory(n, r) > maxScryptMemory {
+		return fmt.Errorf("invalid scrypt r %d or p %d", r, p)
+	}
+	return nil
+}
+
+// scryptMemory returns the bytes taken by scrypt, it's computed in uint64 as
+// 128 * N * R overflows int on 32-bit platforms.
+func scryptMemory(n, r int) uint64 {
+	return 128 * uint64(n) * uint64(r)
+}
+
+// withDefault fills the zero fields with the default options of the cipher,
+// and clears the fields not used by the cipher.
+func (opts Options) withDefault() Options {
+	if opts.Cipher == "" {
+		opts.Cipher = DefaultOptions.Cipher
+	}
+
+	var def Options
+	switch opts.Cipher {
+	case CipherScryptChacha20poly1305:
+		def = DefaultScryptOptions
+		opts.Time, opts.Memory, opts.Threads = 0, 0, 0
+	case CipherArgon2idChacha20poly1305:
+		def = DefaultArgon2idOptions
+		opts.N, opts.R, opts.P = 0, 0, 0
+	default:
+		return opts
+	}
+
+	if opts.N == 0 {
+		opts.N = def.N
+	}
+	if opts.R == 0 {
+		opts.R = def.R
+	}
+	if opts.P == 0 {
+		opts.P = def.P
+	}
+	if opts.Time == 0 {
+		opts.Time = def.Time
+	}
+	if opts.Memory == 0 {
+		opts.Memory = def.Memory
+	}
+	if opts.Threads == 0 {
+		opts.Threads = def.Threads
+	}
+	return opts
+}
+
+// Options returns the options which produce the same cipher and kdf parameters.
+func (params *Params) Options() Options {
+	return Options{
+		Cipher:  params.Cipher,
+		N:       params.N,
+		R:       params.R,
+		P:       params.P,
+		Time:    params.Time,
+		Memory:  params.Memory,
+		Threads: params.Threads,
+	}
+}
+
+// Match check the parameters are produced by the options, nil parameters match nothing.
+func (params *Params) Match(opts Options) bool {
+	return params != nil && params.Options() == opts.withDefault()
+}
+
+// deriveKey derives the encryption key from password by the kdf parameters,
+// the parameters are read from file, so they are bounded before deriving.
+func (params *Params) deriveKey(key []byte) ([]byte, error) {
+	if params.KeyLen <= 0 || params.KeyLen > maxKeyLen {
+		return nil, fmt.Errorf("invalid key length %d", params.KeyLen)
+	}
+	salt, err := hex.DecodeString(params.Salt)
+	if err != nil {
+		return nil, err
+	}
+	switch params.KDF {
+	case KDFScrypt:
+		if err := validateScrypt(params.N, params.R, params.P); err != nil {
+			return nil, err
+		}
+		return scrypt.Key(key, salt, params.N, params.R, params.P, params.KeyLen)
+	case KDFArgon2id:
+		if err := validateArgon2id(params.Time, params.Memory, params.Threads); err != nil {
+			return nil, err
+		}
+		return argon2.IDKey(key, salt, params.Time, params.Memory, params.Threads, uint32(params.KeyLen)), nil
+	default:
+		return nil, fmt.Errorf("kdf %s not supported", params.KDF)
+	}
+}
+
+// Validate checks the parameters read from file are supported and bounded, so the
+// key can be derived by them.
+func (params *Params) Validate() error {
+	if kdfOf(params.Cipher) != params.KDF {
+		return fmt.Errorf("cipher %s with kdf %s not supported", params.Cipher, params.KDF)
+	}
+	if params.KeyLen != chacha20poly1305.KeySize {
+		return errors.New("invalid kdf parameters")
+	}
+	if _, err := hex.DecodeString(params.Salt); err != nil {
+		return err
+	}
+	if params.KDF == KDFArgon2id {
+		return validateArgon2id(params.Time, params.Memory, params.Threads)
+	}
+	return validateScrypt(params.N, params.R, params.P)
+}
+
+// kdfOf returns the kdf used by the cipher.
+func kdfOf(cipherID string) string {
+	switch cipherID {
+	case CipherScryptChacha20poly1305:
+		return KDFScrypt
+	case CipherArgon2idChacha20poly1305:
+		return KDFArgon2id
+	default:
+		return ""
+	}
+}
+
+// additionalData authenticates the parameters together with the encrypted text.
+func (params *Params) additionalData() ([]byte, error) {
+	return json.Marshal(params)
+}
+
+// Key is the encryption key derived from password by the kdf parameters, it encrypts
+// again with the same parameters without running the kdf, so the password needn't be
+// kept. The caller wipes it after use.
+type Key struct {
+	params Params
+	dk     []byte
+}
+
+// NewKey derives the key from password by the options with new salt.
+func NewKey(passwd []byte, opts Options) (*Key, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	opts = opts.withDefault()
+
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return DeriveKey(passwd, &Params{
+		Cipher:  opts.Cipher,
+		KDF:     kdfOf(opts.Cipher),
+		N:       opts.N,
+		R:       opts.R,
+		P:       opts.P,
+		KeyLen:  chacha20poly1305.KeySize,
+		Salt:    hex.EncodeToString(salt),
+		Time:    opts.Time,
+		Memory:  opts.Memory,
+		Threads: opts.Threads,
+	})
+}
+
+// DeriveKey derives the key from password by the parameters which produced the encrypted text.
+func DeriveKey(passwd []byte, params *Params) (*Key, error) {
+	if params == nil {
+		return nil, errors.New("no kdf parameters")
+	}
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	dk, err := params.deriveKey(passwd)
+	if err != nil {
+		return nil, err
+	}
+	defer secure.Wipe(dk)
+	return &Key{params: *params, dk: secure.Copy(dk)}, nil
+}
+
+// Params returns the parameters which the key is derived by.
+func (k *Key) Params() *Params {
+	p := k.params
+	return &p
+}
+
+// Wipe wipes the derived key.
+func (k *Key) Wipe() {
+	secure.Wipe(k.dk)
+}
+
+// EncryptBytes encrypts the secret bytes with new nonce, returns the encrypted text
+// and the parameters of the key.
+func (k *Key) EncryptBytes(text []byte) (string, *Params, error) {
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, err
+	}
+	encry, err := k.seal(text, nonce)
+	if err != nil {
+		return "", nil, err
+	}
+	return encry, k.Params(), nil
+}
+
+// seal encrypts text with the nonce, the nonce is prefixed to the encrypted text.
+func (k *Key) seal(text, nonce []byte) (string, error) {
+	aead, err := chacha20poly1305.New(k.dk)
+	if err != nil {
+		return "", err
+	}
+	ad, err := k.params.additionalData()
 	if err != nil {
 		return "", err
 	}
-	return string(encry), nil
+	encry := aead.Seal(nonce, nonce, text, ad)
+	return base64.StdEncoding.EncodeToString(encry), nil
+}
+
+// DecryptBytes decrypts the text encrypted by the key, params must be the parameters
+// of the key, the caller wipes the returned bytes.
+func (k *Key) DecryptBytes(text string, params *Params) ([]byte, error) {
+	if params == nil || *params != k.params {
+		return nil, errors.New("key does not match the kdf parameters")
+	}
+	aead, err := chacha20poly1305.New(k.dk)
+	if err != nil {
+		return nil, err
+	}
+	ad, err := k.params.additionalData()
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := base64.StdEncoding.DecodeString(text)
+	if err != nil {
+		return nil, err
+	}
+	if len(d) < aead.NonceSize()+aead.Overhead() {
+		return nil, errors.New("invalid encrypted text")
+	}
+	decry := secure.Bytes(len(d) - aead.NonceSize() - aead.Overhead())
+	if _, err := aead.Open(decry[:0], d[:aead.NonceSize()], d[aead.NonceSize():], ad); err != nil {
+		secure.Wipe(decry)
+		return nil, errors.New("invalid password")
+	}
+	return decry, nil
+}
+
+// Encrypt encrypt text, returns the encrypted text and the parameters which produced it.
+func Encrypt(key []byte, text string, opts Options) (string, *Params, error) {
+	return EncryptBytes(key, []byte(text), opts)
+}
+
+// EncryptBytes encrypt the secret bytes, the caller wipes text after use.
+func EncryptBytes(key, text []byte, opts Options) (string, *Params, error) {
+	k, err := NewKey(key, opts)
+	if err != nil {
+		return "", nil, err
+	}
+	defer k.Wipe()
+	return k.EncryptBytes(text)
 }
 
-// Decrypt decrypt text
-func Decrypt(key []byte, text string) (string, error) {
-	decry, err := glosha.Decrypt([]byte(text), key)
+// Decrypt decrypt text with the parameters which produced it,
+// text encrypted without recorded parameters has nil params.
+func Decrypt(key []byte, text string, params *Params) (string, error) {
+	decry, err := DecryptBytes(key, text, params)
 	if err != nil {
 		return "", err
 	}
+	defer secure.Wipe(decry)
 	return string(decry), nil
 }
+
+// DecryptBytes decrypt text into the secret bytes, the caller wipes them after use.
+func DecryptBytes(key []byte, text string, params *Params) ([]byte, error) {
+	if params == nil {
+		decry, err := glosha.Decrypt([]byte(text), key)
+		if err != nil {
+			return nil, err
+		}
+		defer secure.Wipe(decry)
+		return secure.Copy(decry), nil
+	}
+
+	k, err := DeriveKey(key, params)
+	if err != nil {
+		return nil, err
+	}
+	defer k.Wipe()
+	return k.DecryptBytes(text, params)
+}
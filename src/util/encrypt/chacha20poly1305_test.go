@@ -0,0 +1,126 @@
+package encrypt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptParams(t *testing.T) {
+	key := []byte("12345678")
+	text := "secret text"
+
+	opts := Options{Cipher: CipherScryptChacha20poly1305, N: 1 << 10, R: 8, P: 1}
+	encry, params, err := Encrypt(key, text, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, CipherScryptChacha20poly1305, params.Cipher)
+	assert.Equal(t, KDFScrypt, params.KDF)
+	assert.Equal(t, 1<<10, params.N)
+	assert.Len(t, params.Salt, saltLen*2)
+
+	decry, err := Decrypt(key, encry, params)
+	assert.NoError(t, err)
+	assert.Equal(t, text, decry)
+
+	_, err = Decrypt([]byte("87654321"), encry, params)
+	assert.EqualError(t, err, "invalid password")
+
+	// parameters are authenticated with the encrypted text
+	tampered := *params
+	tampered.N = 1 << 11
+	_, err = Decrypt(key, encry, &tampered)
+	assert.Error(t, err)
+
+	// zero options use the default
+	_, params, err = Encrypt(key, text, Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultOptions.N, params.N)
+}
+
+func TestOptionsValidate(t *testing.T) {
+	assert.NoError(t, Options{}.Validate())
+	assert.Error(t, Options{Cipher: "unknown"}.Validate())
+	assert.Error(t, Options{N: 1000}.Validate())
+	assert.Error(t, Options{N: maxScryptN * 2}.Validate())
+	assert.Error(t, Options{R: -1}.Validate())
+	assert.Error(t, Options{R: maxScryptR + 1}.Validate())
+	assert.Error(t, Options{P: maxScryptP + 1}.Validate())
+	assert.Error(t, Options{N: maxScryptN, R: 16}.Validate())
+	assert.Error(t, Options{N: maxScryptN, R: maxScryptR}.Validate())
+	// 4 GiB, which wraps to 0 in 32-bit int.
+	assert.Equal(t, uint64(1)<<32, scryptMemory(maxScryptN, maxScryptR))
+	assert.Equal(t, uint64(1)<<31, scryptMemory(maxScryptN, 16))
+	assert.NoError(t, Options{N: maxScryptN, R: 8, P: maxScryptP}.Validate())
+	assert.Error(t, Options{Cipher: CipherArgon2idChacha20poly1305, Time: maxArgon2idTime + 1}.Validate())
+}
+
+func TestDecryptOversizedParams(t *testing.T) {
+	key := []byte("12345678")
+	encry, params, err := Encrypt(key, "secret text", Options{N: 1 << 10, R: 8, P: 1})
+	assert.NoError(t, err)
+
+	// the parameters of file are rejected before deriving the key.
+	testData := []struct {
+		Name   string
+		Modify func(p *Params)
+		Err    string
+	}{
+		{"oversized r", func(p *Params) { p.R = 1 << 20 }, "invalid scrypt r 1048576 or p 1"},
+		{"oversized p", func(p *Params) { p.P = 1 << 20 }, "invalid scrypt r 8 or p 1048576"},
+		{"oversized r and p", func(p *Params) { p.R, p.P = 1<<15, 1<<14 }, "invalid scrypt r 32768 or p 16384"},
+		{"memory of n and r", func(p *Params) { p.N, p.R = maxScryptN, maxScryptR }, "invalid scrypt r 32 or p 1"},
+		{"zero r", func(p *Params) { p.R = 0 }, "invalid scrypt r 0 or p 1"},
+		{"oversized n", func(p *Params) { p.N = 1 << 30 }, "invalid scrypt N 1073741824"},
+	}
+	for _, d := range testData {
+		tampered := *params
+		d.Modify(&tampered)
+		assert.EqualError(t, tampered.Validate(), d.Err, d.Name)
+		_, err := Decrypt(key, encry, &tampered)
+		assert.EqualError(t, err, d.Err, d.Name)
+	}
+	assert.NoError(t, params.Validate())
+
+	argon := &Params{Cipher: CipherArgon2idChacha20poly1305, KDF: KDFArgon2id, KeyLen: 32, Time: 1 << 30, Memory: 64, Threads: 1}
+	_, err = Decrypt(key, encry, argon)
+	assert.EqualError(t, err, "invalid argon2id time 1073741824")
+
+	_, err = (&Params{KDF: KDFScrypt, N: 16, R: 1, P: 1, KeyLen: 1 << 30}).deriveKey(key)
+	assert.EqualError(t, err, "invalid key length 1073741824")
+}
+
+func TestKey(t *testing.T) {
+	passwd := []byte("12345678")
+	k, err := NewKey(passwd, Options{N: 1 << 10, R: 8, P: 1})
+	assert.NoError(t, err)
+
+	// the text encrypted by the key is decrypted by the password, and the other way around.
+	encry, params, err := k.EncryptBytes([]byte("secret text"))
+	assert.NoError(t, err)
+	assert.Equal(t, k.Params(), params)
+	text, err := Decrypt(passwd, encry, params)
+	assert.NoError(t, err)
+	assert.Equal(t, "secret text", text)
+
+	encry2, params2, err := EncryptBytes(passwd, []byte("secret text"), params.Options())
+	assert.NoError(t, err)
+	assert.NotEqual(t, params.Salt, params2.Salt)
+	_, err = k.DecryptBytes(encry2, params2)
+	assert.EqualError(t, err, "key does not match the kdf parameters")
+	k2, err := DeriveKey(passwd, params2)
+	assert.NoError(t, err)
+	d, err := k2.DecryptBytes(encry2, params2)
+	assert.NoError(t, err)
+	assert.Equal(t, "secret text", string(d))
+
+	// the nonce is new for each encryption.
+	encry3, _, err := k.EncryptBytes([]byte("secret text"))
+	assert.NoError(t, err)
+	assert.NotEqual(t, encry, encry3)
+
+	k.Wipe()
+	_, err = k.DecryptBytes(encry, params)
+	assert.EqualError(t, err, "invalid password")
+	_, err = DeriveKey(passwd, nil)
+	assert.Error(t, err)
+}
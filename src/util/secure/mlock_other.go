@@ -0,0 +1,9 @@
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package secure
+
+// lock is not supported on this os.
+func lock(b []byte) error { return nil }
+
+// unlock is not supported on this os.
+func unlock(addr, n uintptr) error { return nil }
@@ -0,0 +1,24 @@
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package secure
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// lock locks the pages of b in memory.
+func lock(b []byte) error {
+	if err := syscall.Mlock(b); err != nil {
+		return fmt.Errorf("mlock: %v", err)
+	}
+	return nil
+}
+
+// unlock unlocks n bytes of memory at addr.
+func unlock(addr, n uintptr) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_MUNLOCK, addr, n, 0); errno != 0 {
+		return fmt.Errorf("munlock: %v", errno)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+// Package secure keeps secrets in byte buffers which are zeroed explicitly,
+// the buffers are locked in memory to avoid swapping where the os allows.
+package secure
+
+import (
+	"os"
+	"runtime"
+	"sync"
+	"unsafe"
+)
+
+var (
+	mtx sync.Mutex
+	// pages counts the buffers locked on each page, a page is unlocked when
+	// the last buffer on it is wiped.
+	pages = map[uintptr]int{}
+	// buffers records the length of the locked buffers by their address.
+	buffers = map[uintptr]int{}
+	// lockErr is the first mlock or munlock failure.
+	lockErr error
+)
+
+var pageSize = uintptr(os.Getpagesize())
+
+// minCap keeps the buffer out of the tiny allocator, whose objects may never be finalized.
+const minCap = 16
+
+// Bytes allocates n bytes for secret, the memory is locked if possible,
+// the failure of locking is reported by Err. The buffer dropped without Wipe
+// is released when it's garbage collected, before its address can be reused.
+func Bytes(n int) []byte {
+	c := n
+	if c < minCap {
+		c = minCap
+	}
+	b := make([]byte, n, c)
+	if n == 0 {
+		return b
+	}
+	addr := uintptr(unsafe.Pointer(&b[0]))
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	buffers[addr] = n
+	first, last := pageRange(addr, n)
+	for p := first; p <= last; p += pageSize {
+		pages[p]++
+	}
+	setErr(lock(b))
+	runtime.SetFinalizer(&b[0], func(p *byte) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		release(uintptr(unsafe.Pointer(p)))
+	})
+	return b
+}
+
+// Copy copies the secret into buffer allocated by Bytes.
+func Copy(src []byte) []byte {
+	b := Bytes(len(src))
+	copy(b, src)
+	return b
+}
+
+// FromString copies the secret string into buffer allocated by Bytes,
+// the string itself can't be wiped.
+func FromString(s string) []byte {
+	b := Bytes(len(s))
+	copy(b, s)
+	return b
+}
+
+// Wipe zeroes the buffer. The buffer allocated by Bytes is unlocked, only the
+// pages which hold no other locked secret are unlocked.
+func Wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+	if len(b) == 0 {
+		return
+	}
+	mtx.Lock()
+	defer mtx.Unlock()
+	release(uintptr(unsafe.Pointer(&b[0])))
+}
+
+// release unlocks the pages of the buffer allocated by Bytes at addr which hold no other
+// locked secret, mtx must be held.
+func release(addr uintptr) {
+	n, ok := buffers[addr]
+	if !ok {
+		return
+	}
+	delete(buffers, addr)
+	first, last := pageRange(addr, n)
+	for p := first; p <= last; p += pageSize {
+		pages[p]--
+		if pages[p] > 0 {
+			continue
+		}
+		delete(pages, p)
+		setErr(unlock(p, pageSize))
+	}
+}
+
+// IsWiped checks all bytes of the buffer are zero.
+func IsWiped(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Err returns the first failure of locking or unlocking the memory, such as
+// exceeding RLIMIT_MEMLOCK, the secrets are still wiped after use.
+func Err() error {
+	mtx.Lock()
+	defer mtx.Unlock()
+	return lockErr
+}
+
+// pageRange returns the first and last page of n bytes at addr.
+func pageRange(addr uintptr, n int) (uintptr, uintptr) {
+	return addr &^ (pageSize - 1), (addr + uintptr(n) - 1) &^ (pageSize - 1)
+}
+
+func setErr(err error) {
+	if err != nil && lockErr == nil {
+		lockErr = err
+	}
+}
@@ -0,0 +1,78 @@
+package secure
+
+import (
+	"runtime"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWipe(t *testing.T) {
+	b := FromString("secret seed")
+	assert.Equal(t, "secret seed", string(b))
+	assert.False(t, IsWiped(b))
+
+	c := Copy(b)
+	Wipe(b)
+	assert.True(t, IsWiped(b))
+	assert.Len(t, b, 11)
+	assert.Equal(t, "secret seed", string(c))
+
+	assert.True(t, IsWiped(Bytes(32)))
+	assert.True(t, IsWiped(nil))
+	Wipe(nil)
+}
+
+func TestUnlock(t *testing.T) {
+	b := Bytes(int(pageSize) * 2)
+	addr := uintptr(unsafe.Pointer(&b[0]))
+	first, last := pageRange(addr, len(b))
+	assert.True(t, last > first)
+	mtx.Lock()
+	assert.Equal(t, len(b), buffers[addr])
+	assert.Equal(t, 1, pages[last])
+	mtx.Unlock()
+
+	// wiping part of the buffer doesn't unlock it.
+	Wipe(b[1:])
+	mtx.Lock()
+	assert.Equal(t, 1, pages[last])
+	mtx.Unlock()
+
+	Wipe(b)
+	mtx.Lock()
+	_, ok := buffers[addr]
+	assert.False(t, ok)
+	_, ok = pages[last]
+	assert.False(t, ok)
+	mtx.Unlock()
+	// the buffer wiped again is not unlocked twice.
+	Wipe(b)
+	if err := Err(); err != nil {
+		t.Logf("memory is not locked: %v", err)
+	}
+}
+
+func TestRelease(t *testing.T) {
+	// the buffer dropped without Wipe is released when collected.
+	addr := uintptr(unsafe.Pointer(&Bytes(64)[0]))
+	registered := func() bool {
+		mtx.Lock()
+		defer mtx.Unlock()
+		_, ok := buffers[addr]
+		return ok
+	}
+	for i := 0; i < 50 && registered(); i++ {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.False(t, registered())
+
+	// the small buffer isn't batched by the tiny allocator.
+	b := Bytes(8)
+	assert.Len(t, b, 8)
+	assert.Equal(t, minCap, cap(b))
+	Wipe(b)
+}
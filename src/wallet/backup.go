@@ -0,0 +1,268 @@
+package wallet
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/MDLlife/wallet-api/src/util/encrypt"
+	"github.com/MDLlife/wallet-api/src/util/secure"
+)
+
+// BackupVersion is the version of backup archive.
+var BackupVersion = "1"
+
+// backup is the encrypted archive of all wallets.
+type backup struct {
+	Version string          `json:"version"`
+	Crypto  *encrypt.Params `json:"crypto"`
+	Data    string          `json:"data"` // encrypted json of backupData
+}
+
+// backupData key is the wallet id, value is the wallet file whose secrets
+// are encrypted by the backup password.
+type backupData struct {
+	Wallets map[string]json.RawMessage `json:"wallets"`
+}
+
+// ExportReport is the result of ExportBackup.
+type ExportReport struct {
+	Backup  string   `json:"backup"`  // the encrypted archive
+	Skipped []string `json:"skipped"` // ids of the wallets whose password is not passwd, they are not exported
+}
+
+// ImportReport is the result of ImportBackup.
+type ImportReport struct {
+	Imported  []string `json:"imported"`  // ids of imported wallets
+	Conflicts []string `json:"conflicts"` // ids of the wallets already exist, they are not changed
+}
+
+// ExportBackup exports all wallets including lables and address metadata into one archive,
+// the secrets are re-encrypted by backupPasswd, and the archive is encrypted and authenticated
+// by backupPasswd too. The wallets are decrypted by passwd, the unlocked wallets also by their
+// session if passwd is empty, the wallets can't be decrypted are skipped and reported.
+func ExportBackup(passwd, backupPasswd string) (ExportReport, error) {
+	if backupPasswd == "" {
+		return ExportReport{}, errors.New("backup password cannot empty")
+	}
+	p, bp := secure.FromString(passwd), secure.FromString(backupPasswd)
+	defer secure.Wipe(p)
+	defer secure.Wipe(bp)
+	return gWallets.exportBackup(p, bp)
+}
+
+// ImportBackup imports the wallets of the archive exported by ExportBackup, the secrets are
+// encrypted by passwd. Wallets which already exist are reported as conflicts and not changed.
+// If a wallet fails to save, the wallets imported by this call are removed.
+func ImportBackup(blob, backupPasswd, passwd string) (ImportReport, error) {
+	if passwd == "" {
+		return ImportReport{}, errors.New("password cannot empty")
+	}
+	bp := secure.FromString(backupPasswd)
+	defer secure.Wipe(bp)
+	wlts, err := openBackup(blob, bp)
+	if err != nil {
+		return ImportReport{}, err
+	}
+	p := secure.FromString(passwd)
+	defer secure.Wipe(p)
+	return gWallets.importBackup(wlts, p)
+}
+
+func (wlts *wallets) exportBackup(passwd, backupPasswd []byte) (ExportReport, error) {
+	wlts.mtx.Lock()
+	defer wlts.mtx.Unlock()
+
+	report := ExportReport{Skipped: []string{}}
+	data := backupData{Wallets: make(map[string]json.RawMessage, len(wlts.Value))}
+	for id, wlt := range wlts.Value {
+		var pwd []byte
+		if wlt.GetWalletType() != WatchWalletType {
+			// the unlocked wallet takes empty passwd, other passwd must be its password.
+			p, err := wlts.sessionPassword(wlt, passwd)
+			if err == nil {
+				err = wlt.Decryption(p)
+			}
+			if err != nil {
+				report.Skipped = append(report.Skipped, id)
+				continue
+			}
+			pwd = backupPasswd
+		}
+		// the copy is encrypted by the backup password, the wallet is unchanged.
+		var buf bytes.Buffer
+		err := wlt.SaveCopy(&buf, pwd)
+		wlt.Erase()
+		if err != nil {
+			return ExportReport{}, fmt.Errorf("%s: %v", id, err)
+		}
+		data.Wallets[id] = buf.Bytes()
+	}
+	sort.Strings(report.Skipped)
+
+	d, err := json.Marshal(data)
+	if err != nil {
+		return ExportReport{}, err
+	}
+	defer secure.Wipe(d)
+
+	enc, params, err := encrypt.EncryptBytes(backupPasswd, d, getEncryptOptions())
+	if err != nil {
+		return ExportReport{}, err
+	}
+	blob, err := json.Marshal(backup{Version: BackupVersion, Crypto: params, Data: enc})
+	if err != nil {
+		return ExportReport{}, err
+	}
+	report.Backup = string(blob)
+	return report, nil
+}
+
+// openBackup decrypts the archive, and decrypts the secrets of wallets by backupPasswd,
+// the caller erases the wallets.
+func openBackup(blob string, backupPasswd []byte) ([]Walleter, error) {
+	var b backup
+	if err := json.Unmarshal([]byte(blob), &b); err != nil {
+		return nil, errors.New("invalid backup")
+	}
+	if b.Version != BackupVersion {
+		return nil, fmt.Errorf("backup version %s not supported", b.Version)
+	}
+	if b.Crypto == nil {
+		return nil, errors.New("invalid backup")
+	}
+
+	d, err := encrypt.DecryptBytes(backupPasswd, b.Data, b.Crypto)
+	if err != nil {
+		return nil, errors.New("backup password incorrect or backup tampered")
+	}
+	defer secure.Wipe(d)
+
+	var data backupData
+	if err := json.Unmarshal(d, &data); err != nil {
+		return nil, errors.New("invalid backup")
+	}
+
+	ids := make([]string, 0, len(data.Wallets))
+	for id := range data.Wallets {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	wlts := make([]Walleter, 0, len(ids))
+	erase := func() {
+		for _, w := range wlts {
+			w.Erase()
+		}
+	}
+	for _, id := range ids {
+		wlt, err := loadBackupWallet(id, data.Wallets[id], backupPasswd)
+		if err != nil {
+			erase()
+			return nil, fmt.Errorf("%s: %v", id, err)
+		}
+		wlts = append(wlts, wlt)
+	}
+	return wlts, nil
+}
+
+// loadBackupWallet loads the wallet file in backup, the creator is chosen by the id prefix.
+func loadBackupWallet(id string, d, backupPasswd []byte) (Walleter, error) {
+	if err := validateBackupID(id); err != nil {
+		return nil, err
+	}
+	newWlt, ok := gWalletCreators[strings.SplitN(id, "_", 2)[0]]
+	if !ok {
+		return nil, errors.New("wallet not supported")
+	}
+	d, _, err := migrate(d)
+	if err != nil {
+		return nil, err
+	}
+	wlt := newWlt()
+	if err := wlt.Load(bytes.NewReader(d)); err != nil {
+		return nil, err
+	}
+	if wlt.GetID() != id {
+		return nil, errors.New("wallet id mismatch")
+	}
+	if _, ok := wlt.(*WatchWallet); ok {
+		return wlt, nil
+	}
+	if err := wlt.Decryption(backupPasswd); err != nil {
+		return nil, err
+	}
+	return wlt, nil
+}
+
+// validateBackupID checks the id read from backup is $coin_$key or $watch_$coin_$key, the key
+// is the base58 address or hex made by the creators, so the id used as the file name can't
+// contain path separators or "..".
+func validateBackupID(id string) error {
+	parts := strings.Split(id, "_")
+	if len(parts) == 3 && parts[0] == WatchType {
+		parts = parts[1:]
+	}
+	if len(parts) != 2 || parts[0] == WatchType || !isAlnum(parts[1]) {
+		return errors.New("invalid wallet id")
+	}
+	if _, ok := gWalletCreators[parts[0]]; !ok {
+		return errors.New("wallet not supported")
+	}
+	return nil
+}
+
+// isAlnum checks s is not empty and only has ascii letters and digits, which cover
+// base58 and hex.
+func isAlnum(s string) bool {
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z') {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func (wlts *wallets) importBackup(ws []Walleter, passwd []byte) (ImportReport, error) {
+	wlts.mtx.Lock()
+	defer wlts.mtx.Unlock()
+
+	for _, wlt := range ws {
+		if err := validateBackupID(wlt.GetID()); err != nil {
+			for _, w := range ws {
+				w.Erase()
+			}
+			return ImportReport{}, fmt.Errorf("%s: %v", wlt.GetID(), err)
+		}
+	}
+
+	report := ImportReport{Imported: []string{}, Conflicts: []string{}}
+	for i, wlt := range ws {
+		if _, ok := wlts.Value[wlt.GetID()]; ok {
+			wlt.Erase()
+			report.Conflicts = append(report.Conflicts, wlt.GetID())
+			continue
+		}
+		pwd := passwd
+		if _, ok := wlt.(*WatchWallet); ok {
+			pwd = nil
+		}
+		// encrypted by passwd and erased in store.
+		if err := wlts.store(wlt, pwd); err != nil {
+			for _, w := range ws[i:] {
+				w.Erase()
+			}
+			// roll back, the backup is imported entirely or not at all.
+			if rerr := wlts.removeIDs(report.Imported); rerr != nil {
+				return ImportReport{}, fmt.Errorf("%s: %v, roll back failed: %v", wlt.GetID(), err, rerr)
+			}
+			return ImportReport{}, fmt.Errorf("%s: %v", wlt.GetID(), err)
+		}
+		wlts.Value[wlt.GetID()] = wlt
+		report.Imported = append(report.Imported, wlt.GetID())
+	}
+	return report, nil
+}
@@ -2,34 +2,59 @@ package wallet
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/MDLlife/wallet-api/src/coin"
 	"github.com/MDLlife/wallet-api/src/util/encrypt"
+	"github.com/MDLlife/wallet-api/src/util/secure"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/text/unicode/norm"
 )
 
 const (
-	metaEncrypted = "encrypted" // whether the wallet is encrypted
-	metaVersion   = "version"   // wallet version
-	metaInitSeed  = "init_seed" // wallet seed
-	metaSeed      = "seed"      // seed for generating next address
+	metaEncrypted  = "encrypted"  // whether the wallet is encrypted
+	metaVersion    = "version"    // wallet version
+	metaInitSeed   = "init_seed"  // wallet seed
+	metaSeed       = "seed"       // seed for generating next address
+	metaMacKey     = "mac_key"    // key of the public fields mac
+	metaPassphrase = "passphrase" // bip39 passphrase
 )
 
+var errWalletTampered = errors.New("wallet file tampered, public fields do not match the mac")
+
 // Wallet wallet struct
 type Wallet struct {
 	Version        string              `json:"version"`           // version
 	ID             string              `json:"id"`                // wallet id
-	InitSeed       string              `json:"init_seed"`         // Init seed, used to recover the wallet.
-	Seed           string              `json:"seed"`              // used to track the latset seed
+	InitSeed       []byte              `json:"-"`                 // Init seed, used to recover the wallet.
+	Seed           []byte              `json:"-"`                 // used to track the latset seed
+	Passphrase     []byte              `json:"-"`                 // bip39 passphrase, the seed is derived from init seed and passphrase.
 	Lable          string              `json:"lable"`             // lable
+	Group          string              `json:"group,omitempty"`   // group id of the wallets created from one seed for every coin
 	AddressEntries []coin.AddressEntry `json:"entries,omitempty"` // address entries.
 	Type           string              `json:"type"`              // wallet type
 	Tm             string              `json:"tm"`
 	WalletType     string              `json:"wallet_type"`
 	Secrets        string              `json:"secrets"`
+	Crypto         *encrypt.Params     `json:"crypto,omitempty"`      // cipher and kdf parameters of secrets
+	Mac            string              `json:"mac,omitempty"`         // mac of public fields, keyed by the key in secrets
+	MacPending     bool                `json:"mac_pending,omitempty"` // saved before the mac, the mac is added on next unlock.
+	MacLable       *string             `json:"mac_lable,omitempty"`   // lable covered by the mac saved before 0.5, dropped when the mac is renewed.
+
+	cipherOpts *encrypt.Options  // cipher options for next encryption
+	unlocked   bool              // secrets are kept decrypted until Lock
+	key        *encrypt.Key      // key derived from the password on unlock, kept until Lock instead of the password
+	verified   bool              // public fields are verified by the mac since loaded
+	secrets    map[string][]byte // decrypted secret keys, key is address
 }
 
 // GetID return wallet id.
@@ -44,8 +69,102 @@ func (wlt *Wallet) SetID(id string) {
 
 // SetSeed initialize the wallet seed.
 func (wlt *Wallet) SetSeed(seed string) {
-	wlt.InitSeed = seed
-	wlt.Seed = seed
+	secure.Wipe(wlt.InitSeed)
+	secure.Wipe(wlt.Seed)
+	wlt.InitSeed = secure.FromString(seed)
+	wlt.Seed = secure.FromString(seed)
+}
+
+// SetPassphrase set the bip39 passphrase, the seed for generating addresses is derived
+// from the init seed and passphrase. Must be called after SetSeed.
+func (wlt *Wallet) SetPassphrase(passphrase string) {
+	secure.Wipe(wlt.Passphrase)
+	wlt.Passphrase = nil
+	if passphrase == "" {
+		return
+	}
+	wlt.Passphrase = secure.FromString(passphrase)
+	root := mnemonicSeed(wlt.InitSeed, wlt.Passphrase)
+	defer secure.Wipe(root)
+	secure.Wipe(wlt.Seed)
+	wlt.Seed = hexBytes(root)
+}
+
+// mnemonicSeed derives the bip39 seed of the mnemonic and passphrase, both are NFKD
+// normalized as bip39 requires, the caller wipes it.
+func mnemonicSeed(mnemonic, passphrase []byte) []byte {
+	m, p := nfkd(mnemonic), nfkd(passphrase)
+	defer secure.Wipe(m)
+	defer secure.Wipe(p)
+	salt := secure.Bytes(len("mnemonic") + len(p))
+	defer secure.Wipe(salt)
+	copy(salt, "mnemonic")
+	copy(salt[len("mnemonic"):], p)
+	k := pbkdf2.Key(m, salt, 2048, 64, sha512.New)
+	defer secure.Wipe(k)
+	return secure.Copy(k)
+}
+
+// nfkd returns the NFKD form of b in a new buffer, the caller wipes it.
+func nfkd(b []byte) []byte {
+	if norm.NFKD.IsNormal(b) {
+		return secure.Copy(b)
+	}
+	n := norm.NFKD.Bytes(b)
+	defer secure.Wipe(n)
+	return secure.Copy(n)
+}
+
+// AddEntries appends the new address entries with their secret keys,
+// the wallet takes the secret buffers and wipes them on erase.
+// The derivation index and created time of entries are set in place.
+func (wlt *Wallet) AddEntries(entries []coin.AddressEntry, secrets [][]byte) error {
+	if len(entries) != len(secrets) {
+		return errors.New("entries and secrets mismatch")
+	}
+	if wlt.secrets == nil {
+		wlt.secrets = make(map[string][]byte)
+	}
+	now := time.Now().Unix()
+	for i := range entries {
+		// addresses of deterministic wallet are derived in order.
+		entries[i].Index = len(wlt.AddressEntries)
+		if wlt.WalletType == CollectionWalletType {
+			entries[i].Index = -1
+		}
+		entries[i].Created = now
+		entries[i].Secret = ""
+		wlt.AddressEntries = append(wlt.AddressEntries, entries[i])
+		wlt.secrets[entries[i].Address] = secrets[i]
+	}
+	return nil
+}
+
+// GetAddressEntries returns copy of the address entries with their metadata.
+func (wlt *Wallet) GetAddressEntries() []coin.AddressEntry {
+	return append([]coin.AddressEntry{}, wlt.AddressEntries...)
+}
+
+// SetAddressLabel set the label of address.
+func (wlt *Wallet) SetAddressLabel(addr, label string) error {
+	for i := range wlt.AddressEntries {
+		if wlt.AddressEntries[i].Address == addr {
+			wlt.AddressEntries[i].Label = label
+			return nil
+		}
+	}
+	return fmt.Errorf("%s addr does not exist in wallet", addr)
+}
+
+// SetAddressUsed set whether the address has received coins.
+func (wlt *Wallet) SetAddressUsed(addr string, used bool) error {
+	for i := range wlt.AddressEntries {
+		if wlt.AddressEntries[i].Address == addr {
+			wlt.AddressEntries[i].Used = used
+			return nil
+		}
+	}
+	return fmt.Errorf("%s addr does not exist in wallet", addr)
 }
 
 // SetLable set wallet lable.
@@ -59,6 +178,11 @@ func (wlt *Wallet) SetConstant() {
 	wlt.WalletType = WalletType
 }
 
+// SetWalletType set the wallet type, deterministic or collection.
+func (wlt *Wallet) SetWalletType(wltType string) {
+	wlt.WalletType = wltType
+}
+
 // SetTime set wallet created time.
 func (wlt *Wallet) SetTime(tm string) {
 	wlt.Tm = tm
@@ -73,24 +197,85 @@ func (wlt *Wallet) GetAddresses() []string {
 	return addrs
 }
 
-// GetKeypair get pub/sec key pair of specific address
-func (wlt *Wallet) GetKeypair(addr, passwd string) (string, string, error) {
-	if err := wlt.Decryption(passwd); err != nil {
+// GetKeypair get pub/sec key pair of specific address, the seckey is in hex.
+func (wlt *Wallet) GetKeypair(addr string, passwd []byte) (string, string, error) {
+	sec, err := wlt.GetSeckey(addr, passwd)
+	if err != nil {
 		return "", "", err
 	}
-
-	defer wlt.erase()
+	defer secure.Wipe(sec)
 
 	for _, e := range wlt.AddressEntries {
 		if e.Address == addr {
-			return e.Public, e.Secret, nil
+			return e.Public, hex.EncodeToString(sec), nil
 		}
 	}
 	return "", "", fmt.Errorf("%s addr does not exist in wallet", addr)
 }
 
+// GetSeckey get the secret key of specific address, the caller wipes it after use.
+func (wlt *Wallet) GetSeckey(addr string, passwd []byte) ([]byte, error) {
+	if err := wlt.Decryption(passwd); err != nil {
+		return nil, err
+	}
+
+	defer wlt.erase()
+
+	sec, ok := wlt.secrets[addr]
+	if !ok {
+		return nil, fmt.Errorf("%s addr does not exist in wallet", addr)
+	}
+	return secure.Copy(sec), nil
+}
+
+// GetSeckeys returns the secret keys of addrs by one decryption, the keys are in buffers
+// allocated by secure.Bytes, the caller wipes them after use.
+func (wlt *Wallet) GetSeckeys(addrs []string, passwd []byte) ([][]byte, error) {
+	if err := wlt.Decryption(passwd); err != nil {
+		return nil, err
+	}
+
+	defer wlt.erase()
+
+	secs := make([][]byte, len(addrs))
+	for i, addr := range addrs {
+		sec, ok := wlt.secrets[addr]
+		if !ok {
+			for _, s := range secs[:i] {
+				secure.Wipe(s)
+			}
+			return nil, fmt.Errorf("%s addr does not exist in wallet", addr)
+		}
+		secs[i] = secure.Copy(sec)
+	}
+	return secs, nil
+}
+
+// SaveCopy saves the copy of the decrypted wallet encrypted by passwd, the wallet itself
+// is unchanged, so the unlocked wallet keeps its secrets and key.
+func (wlt *Wallet) SaveCopy(w io.Writer, passwd []byte) error {
+	c := wlt.Copy()
+	defer c.erase()
+	c.Seed = copyBytes(wlt.Seed)
+	c.InitSeed = copyBytes(wlt.InitSeed)
+	c.Passphrase = copyBytes(wlt.Passphrase)
+	c.secrets = make(map[string][]byte, len(wlt.secrets))
+	for addr, sec := range wlt.secrets {
+		c.secrets[addr] = secure.Copy(sec)
+	}
+	return c.Save(w, passwd)
+}
+
+// copyBytes copies the secret by secure.Copy, nil stays nil.
+func copyBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return secure.Copy(b)
+}
+
 // Save save the wallet
-func (wlt *Wallet) Save(w io.Writer, passwd string) error {
+func (wlt *Wallet) Save(w io.Writer, passwd []byte) error {
 	if err := wlt.Encryption(passwd); err != nil {
 		return err
 	}
@@ -102,50 +287,197 @@ func (wlt *Wallet) Save(w io.Writer, passwd string) error {
 	return err
 }
 
-// Encryption encryption seed and private key.
-func (wlt *Wallet) Encryption(passwd string) error {
-	if wlt.Seed == "" || wlt.InitSeed == "" {
-		return errors.New("empty seed")
+// Encryption encryption seed and private key, passwd is used as the key without copy.
+// The unlocked wallet is encrypted by the key derived on unlock if passwd is empty.
+func (wlt *Wallet) Encryption(passwd []byte) error {
+	seeds := map[string][]byte{}
+	if wlt.WalletType != CollectionWalletType {
+		if len(wlt.Seed) == 0 || len(wlt.InitSeed) == 0 {
+			return errors.New("empty seed")
+		}
+		seeds[metaSeed] = wlt.Seed
+		seeds[metaInitSeed] = wlt.InitSeed
+		if len(wlt.Passphrase) > 0 {
+			seeds[metaPassphrase] = wlt.Passphrase
+		}
 	}
-	// temporary map
-	metaMap := make(map[string]string)
-	metaMap[metaSeed] = wlt.Seed
-	metaMap[metaInitSeed] = wlt.InitSeed
-	for _, entry := range wlt.AddressEntries {
-		metaMap[entry.Address] = entry.Secret
+	// the decrypted secrets are erased even if failed.
+	defer wlt.erase()
+
+	// the mac key is kept in secrets, so it's protected by the password.
+	macKey := secure.Bytes(32)
+	defer secure.Wipe(macKey)
+	if _, err := rand.Read(macKey); err != nil {
+		return err
 	}
 
-	// delete critical info
-	defer func() {
-		for k := range metaMap {
-			metaMap[k] = ""
-			delete(metaMap, k)
+	// temporary map, the hex buffers are wiped after encryption.
+	metaMap := make(map[string][]byte)
+	defer wipeSecrets(metaMap)
+	metaMap[metaMacKey] = hexBytes(macKey)
+	for _, entry := range wlt.AddressEntries {
+		sec, ok := wlt.secrets[entry.Address]
+		if !ok {
+			return fmt.Errorf("address %s no secret", entry.Address)
 		}
-	}()
-
-	secretsBinary, err := json.Marshal(metaMap)
+		metaMap[entry.Address] = hexBytes(sec)
+	}
+	// seeds are owned by the wallet, they are not wiped with the map.
+	secretsBinary, err := marshalSecrets(metaMap, seeds)
 	if err != nil {
 		return err
 	}
+	defer secure.Wipe(secretsBinary)
 
-	sb, err := encrypt.Encrypt([]byte(passwd), string(secretsBinary))
+	sb, params, err := wlt.encrypt(passwd, secretsBinary)
 	if err != nil {
 		return err
 	}
-	wlt.Secrets = string(sb)
+	wlt.Secrets = sb
+	wlt.Crypto = params
+	wlt.cipherOpts = nil
+	wlt.MacPending = false
+	wlt.MacLable = nil
+	if wlt.Mac, err = wlt.mac(macKey); err != nil {
+		return err
+	}
+	wlt.verified = true
+	return nil
+}
 
-	wlt.erase()
+// encrypt encrypts the secrets by passwd, the key derived from passwd replaces the key of
+// the unlocked wallet. Empty passwd uses the key of the unlocked wallet, which can't change
+// the cipher.
+func (wlt *Wallet) encrypt(passwd, secrets []byte) (string, *encrypt.Params, error) {
+	if len(passwd) == 0 && wlt.key != nil {
+		if wlt.cipherOpts != nil && !wlt.key.Params().Match(*wlt.cipherOpts) {
+			return "", nil, errors.New("password is required to change the cipher")
+		}
+		return wlt.key.EncryptBytes(secrets)
+	}
 
-	return nil
+	key, err := encrypt.NewKey(passwd, wlt.encryptOptions())
+	if err != nil {
+		return "", nil, err
+	}
+	sb, params, err := key.EncryptBytes(secrets)
+	if err != nil || !wlt.unlocked {
+		key.Wipe()
+		return sb, params, err
+	}
+	wlt.setKey(key)
+	return sb, params, nil
+}
+
+// setKey replaces the key kept by the unlocked wallet.
+func (wlt *Wallet) setKey(key *encrypt.Key) {
+	if wlt.key != nil {
+		wlt.key.Wipe()
+	}
+	wlt.key = key
+}
+
+// encryptOptions returns the options set by SetCipher, or the options of the recorded
+// parameters, wallet without parameters uses the default options.
+func (wlt *Wallet) encryptOptions() encrypt.Options {
+	if wlt.cipherOpts != nil {
+		return *wlt.cipherOpts
+	}
+	if wlt.Crypto != nil {
+		return wlt.Crypto.Options()
+	}
+	return getEncryptOptions()
+}
+
+// GetCrypto returns the cipher and kdf parameters of secrets.
+func (wlt *Wallet) GetCrypto() *encrypt.Params {
+	return wlt.Crypto
+}
+
+// SetCipher set the cipher and kdf options used by next encryption.
+func (wlt *Wallet) SetCipher(opts encrypt.Options) {
+	wlt.cipherOpts = &opts
 }
 
-// Load load wallet from reader.
-func (wlt *Wallet) Load(r io.Reader, passwd string) error {
-	err := json.NewDecoder(r).Decode(wlt)
+// mac computes the hmac-sha256 of public fields, three fields are excluded on purpose:
+// the version is rewritten by migrations without the password, a downgraded version can't
+// drop the mac as the mac key stays in the secrets. The lable and the metadata of entries,
+// label, index, created and used, are set without the password, only address and pubkey of
+// entries are covered, the keys aren't derived by the index. The mac saved before 0.5 covers
+// the lable, which is kept in MacLable until the mac is renewed.
+func (wlt *Wallet) mac(key []byte) (string, error) {
+	type entry struct {
+		Address string `json:"address"`
+		Public  string `json:"pubkey"`
+	}
+	fields := struct {
+		ID         string          `json:"id"`
+		Lable      *string         `json:"lable,omitempty"`
+		Group      string          `json:"group,omitempty"`
+		Entries    []entry         `json:"entries"`
+		Type       string          `json:"type"`
+		Tm         string          `json:"tm"`
+		WalletType string          `json:"wallet_type"`
+		Crypto     *encrypt.Params `json:"crypto"`
+	}{
+		ID:         wlt.ID,
+		Lable:      wlt.MacLable,
+		Group:      wlt.Group,
+		Entries:    []entry{},
+		Type:       wlt.Type,
+		Tm:         wlt.Tm,
+		WalletType: wlt.WalletType,
+		Crypto:     wlt.Crypto,
+	}
+	for _, e := range wlt.AddressEntries {
+		fields.Entries = append(fields.Entries, entry{Address: e.Address, Public: e.Public})
+	}
+	d, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	h := hmac.New(sha256.New, key)
+	h.Write(d)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// verifyMac checks the public fields by the mac key decrypted from secrets, only the
+// wallet migrated from the version before the mac may have no mac key, it's saved with
+// the mac on next unlock. The mac key can't be removed without the password.
+func (wlt *Wallet) verifyMac(metaMap map[string][]byte) error {
+	k, ok := metaMap[metaMacKey]
+	if !ok {
+		if wlt.MacPending {
+			return nil
+		}
+		return errWalletTampered
+	}
+	key, err := unhexBytes(k)
+	if err != nil {
+		return errWalletTampered
+	}
+	defer secure.Wipe(key)
+	mac, err := wlt.mac(key)
 	if err != nil {
 		return err
 	}
-	return wlt.Decryption(passwd)
+	if !hmac.Equal([]byte(mac), []byte(wlt.Mac)) {
+		return errWalletTampered
+	}
+	wlt.verified = true
+	return nil
+}
+
+// Load load wallet from reader, the secrets are decrypted on demand by the wallet password,
+// the mac of public fields is verified with the secrets as its key needs the password.
+func (wlt *Wallet) Load(r io.Reader) error {
+	if err := json.NewDecoder(r).Decode(wlt); err != nil {
+		return err
+	}
+	if _, err := hex.DecodeString(wlt.Mac); err != nil {
+		return errWalletTampered
+	}
+	return nil
 }
 
 // Validate validates the wallet
@@ -154,68 +486,142 @@ func (wlt *Wallet) Validate() error {
 		return errors.New("wallet id not set")
 	}
 
-	if wlt.Seed == "" {
-		return errors.New("seed field not set")
+	// collection wallet holds imported keys without seed.
+	switch wlt.WalletType {
+	case WalletType:
+		if len(wlt.Seed) == 0 {
+			return errors.New("seed field not set")
+		}
+	case CollectionWalletType:
+	default:
+		return errors.New("wallet type invalid")
 	}
 
 	if wlt.Type == "" {
 		return errors.New("type field not set")
 	}
 
-	if wlt.WalletType != "deterministic" {
-		return errors.New("wallet type invalid")
-	}
-
 	return nil
 }
 
 // IsPasswordCorrect check password correct or not.
-func (wlt *Wallet) IsPasswordCorrect(passwd string) (err error) {
+func (wlt *Wallet) IsPasswordCorrect(passwd []byte) (err error) {
 	// first
 	if wlt.Secrets == "" {
 		return nil
 	}
-	_, err = encrypt.Decrypt([]byte(passwd), wlt.Secrets)
+	metaMap, key, err := wlt.decryptSecrets(passwd)
+	wipeSecrets(metaMap)
+	if key != nil {
+		key.Wipe()
+	}
 	return
 }
 
-// Decryption decryption wallet recover seed and private key.
-func (wlt *Wallet) Decryption(passwd string) error {
-	if wlt.Secrets == "" {
-		return errors.New("secrets is empty")
+// decryptSecrets decrypts the secrets and verifies the public fields, returns the secrets
+// and the key derived from passwd, the key is nil if the secrets are encrypted by the legacy
+// cipher. The caller wipes the returned secrets and key.
+func (wlt *Wallet) decryptSecrets(passwd []byte) (map[string][]byte, *encrypt.Key, error) {
+	var (
+		metaMapB []byte
+		key      *encrypt.Key
+		err      error
+	)
+	if wlt.Crypto == nil {
+		// the legacy cipher derives the key by itself.
+		metaMapB, err = encrypt.DecryptBytes(passwd, wlt.Secrets, nil)
+	} else if key, err = encrypt.DeriveKey(passwd, wlt.Crypto); err == nil {
+		metaMapB, err = key.DecryptBytes(wlt.Secrets, wlt.Crypto)
 	}
-	metaMapB, err := encrypt.Decrypt([]byte(passwd), wlt.Secrets)
 	if err != nil {
-		return err
+		if key != nil {
+			key.Wipe()
+		}
+		return nil, nil, errPasswordIncorrect
+	}
+	defer secure.Wipe(metaMapB)
+	// the legacy cipher may decrypt garbage by wrong password.
+	metaMap, err := unmarshalSecrets(metaMapB)
+	if err == nil {
+		err = wlt.verifyMac(metaMap)
+	} else {
+		err = errPasswordIncorrect
 	}
-	metaMap := make(map[string]string)
-	err = json.Unmarshal([]byte(metaMapB), &metaMap)
 	if err != nil {
-		return err
+		wipeSecrets(metaMap)
+		if key != nil {
+			key.Wipe()
+		}
+		return nil, nil, err
 	}
-	defer func() {
-		for k := range metaMap {
-			metaMap[k] = ""
-			delete(metaMap, k)
+	return metaMap, key, nil
+}
+
+// Decryption decryption wallet recover seed and private key,
+// the unlocked wallet is already decrypted, passwd must be verified by the caller.
+func (wlt *Wallet) Decryption(passwd []byte) error {
+	if wlt.unlocked {
+		return nil
+	}
+	key, err := wlt.decrypt(passwd)
+	if key != nil {
+		key.Wipe()
+	}
+	return err
+}
+
+// decrypt decrypts the secrets into the wallet, returns the key derived from passwd,
+// which is nil for the legacy cipher, the caller wipes the key.
+func (wlt *Wallet) decrypt(passwd []byte) (*encrypt.Key, error) {
+	if wlt.Secrets == "" {
+		return nil, errors.New("secrets is empty")
+	}
+	metaMap, key, err := wlt.decryptSecrets(passwd)
+	if err != nil {
+		return nil, err
+	}
+	defer wipeSecrets(metaMap)
+	if err := wlt.setSecrets(metaMap); err != nil {
+		if key != nil {
+			key.Wipe()
 		}
-	}()
+		return nil, err
+	}
+	return key, nil
+}
 
+// setSecrets replaces the decrypted secrets of the wallet by the secrets of metaMap.
+func (wlt *Wallet) setSecrets(metaMap map[string][]byte) error {
 	seed, ok := metaMap[metaSeed]
-	if !ok {
+	if !ok && wlt.WalletType != CollectionWalletType {
 		return errors.New("no seed")
 	}
 	initSeed, ok := metaMap[metaInitSeed]
-	if !ok {
+	if !ok && wlt.WalletType != CollectionWalletType {
 		return errors.New("no init seed")
 	}
-	wlt.Seed = seed
-	wlt.InitSeed = initSeed
-	for i, entry := range wlt.AddressEntries {
+	// wipe the secrets decrypted before.
+	wlt.erase()
+	if seed != nil {
+		wlt.Seed = secure.Copy(seed)
+		wlt.InitSeed = secure.Copy(initSeed)
+	}
+	if passphrase, ok := metaMap[metaPassphrase]; ok {
+		wlt.Passphrase = secure.Copy(passphrase)
+	}
+	wlt.secrets = make(map[string][]byte)
+	for _, entry := range wlt.AddressEntries {
 		secret, ok := metaMap[entry.Address]
 		if !ok {
+			wlt.erase()
 			return fmt.Errorf("address %s no secret", entry.Address)
 		}
-		wlt.AddressEntries[i].Secret = secret
+		sec, err := unhexBytes(secret)
+		if err != nil {
+			wlt.erase()
+			return fmt.Errorf("address %s invalid secret", entry.Address)
+		}
+		wlt.secrets[entry.Address] = sec
 	}
 	return nil
 }
@@ -226,19 +632,60 @@ func (wlt *Wallet) String() string {
 	for _, entry := range wlt.AddressEntries {
 		s1 += fmt.Sprintf("\t\taddress:%s\n", entry.Address)
 		s1 += fmt.Sprintf("\t\tpubkey:%s\n", entry.Public)
-		s1 += fmt.Sprintf("\t\tseckey:%s\n", entry.Secret)
+		s1 += fmt.Sprintf("\t\tseckey:%x\n", wlt.secrets[entry.Address])
 		s1 += "\n"
 	}
 	return s1 + "\t]\n"
 }
 
-// erase erase critical field such as seed, private key
+// Erase erase the decrypted secrets, the wallet only keeps public fields
+// and the encrypted secrets when no operation is using the secrets.
+func (wlt *Wallet) Erase() {
+	wlt.erase()
+}
+
+// Unlock decrypts the secrets and keeps them until Lock, the key derived from passwd is
+// kept to encrypt the wallet without the password, passwd itself isn't kept.
+func (wlt *Wallet) Unlock(passwd []byte) error {
+	if wlt.unlocked {
+		return nil
+	}
+	key, err := wlt.decrypt(passwd)
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		// the legacy cipher has no key to keep, the next encryption uses the new key.
+		if key, err = encrypt.NewKey(passwd, wlt.encryptOptions()); err != nil {
+			wlt.erase()
+			return err
+		}
+	}
+	wlt.key = key
+	wlt.unlocked = true
+	return nil
+}
+
+// Lock erases the secrets and the key kept by Unlock.
+func (wlt *Wallet) Lock() {
+	wlt.unlocked = false
+	wlt.setKey(nil)
+	wlt.erase()
+}
+
+// erase erase critical field such as seed, private key, the unlocked wallet keeps them.
 func (wlt *Wallet) erase() {
-	wlt.Seed = ""
-	wlt.InitSeed = ""
-	for i := range wlt.AddressEntries {
-		wlt.AddressEntries[i].Secret = ""
+	if wlt.unlocked {
+		return
 	}
+	secure.Wipe(wlt.Seed)
+	secure.Wipe(wlt.InitSeed)
+	secure.Wipe(wlt.Passphrase)
+	wlt.Seed = nil
+	wlt.InitSeed = nil
+	wlt.Passphrase = nil
+	wipeSecrets(wlt.secrets)
+	wlt.secrets = nil
 }
 
 // GetType returns the wallet type
@@ -246,25 +693,84 @@ func (wlt *Wallet) GetType() string {
 	return wlt.Type
 }
 
-// GetSeed returns the wallet seed
-func (wlt *Wallet) GetSeed(passwd string) string {
+// GetLable returns the wallet lable.
+func (wlt *Wallet) GetLable() string {
+	return wlt.Lable
+}
+
+// SetGroup set the group id of linked wallets.
+func (wlt *Wallet) SetGroup(group string) {
+	wlt.Group = group
+}
+
+// GetGroup returns the group id of linked wallets, empty if the wallet isn't linked.
+func (wlt *Wallet) GetGroup() string {
+	return wlt.Group
+}
+
+// GetTime returns the wallet created time.
+func (wlt *Wallet) GetTime() string {
+	return wlt.Tm
+}
+
+// GetVersion returns the wallet file version.
+func (wlt *Wallet) GetVersion() string {
+	return wlt.Version
+}
+
+// GetWalletType returns the wallet type, such as deterministic, collection or watch-only.
+func (wlt *Wallet) GetWalletType() string {
+	return wlt.WalletType
+}
+
+// IsMacPending checks the wallet was saved before the mac, or before the lable was left
+// out of the mac, and the mac isn't added or renewed yet.
+func (wlt *Wallet) IsMacPending() bool {
+	return wlt.MacPending || wlt.MacLable != nil
+}
+
+// IsVerified checks the public fields were verified by the mac since the wallet was loaded,
+// the mac key needs the password, so the loaded wallet is unverified until it's decrypted.
+func (wlt *Wallet) IsVerified() bool {
+	return wlt.verified
+}
+
+// GetSeed returns the wallet seed, the string returned to the caller can't be wiped.
+// The collection wallet has no seed.
+func (wlt *Wallet) GetSeed(passwd []byte) (string, error) {
+	if err := wlt.Decryption(passwd); err != nil {
+		return "", err
+	}
+	defer wlt.erase()
+	return string(wlt.InitSeed), nil
+}
+
+// GetSeedBytes returns the wallet seed in buffer allocated by secure.Bytes, the caller
+// wipes it after use. The collection wallet has no seed.
+func (wlt *Wallet) GetSeedBytes(passwd []byte) ([]byte, error) {
 	if err := wlt.Decryption(passwd); err != nil {
-		return ""
+		return nil, err
 	}
 	defer wlt.erase()
-	return wlt.InitSeed
+	return secure.Copy(wlt.InitSeed), nil
 }
 
-// Copy return the copy of self, for thread safe.
+// Copy return the copy of self without decrypted secrets, for thread safe.
 func (wlt *Wallet) Copy() Wallet {
 	return Wallet{
 		ID:             wlt.ID,
 		Lable:          wlt.Lable,
-		AddressEntries: wlt.AddressEntries,
+		Group:          wlt.Group,
+		AddressEntries: append([]coin.AddressEntry{}, wlt.AddressEntries...),
 		Tm:             wlt.Tm,
 		WalletType:     wlt.WalletType,
 		Version:        wlt.Version,
 		Type:           wlt.Type,
 		Secrets:        wlt.Secrets,
+		Crypto:         wlt.Crypto,
+		Mac:            wlt.Mac,
+		MacPending:     wlt.MacPending,
+		MacLable:       wlt.MacLable,
+		verified:       wlt.verified,
 	}
 }
@@ -0,0 +1,101 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DefaultGapLimit is the number of consecutive unused addresses after which
+// the address discovery stops.
+const DefaultGapLimit = 20
+
+// UsageChecker returns the addresses which have been used on the chain,
+// usually it queries the coin node.
+type UsageChecker func(addrs []string) (map[string]bool, error)
+
+// Restore restores wallet of bip39 mnemonic and passphrase, addresses are derived in
+// batches of gap and checked by used, the discovery stops after gap consecutive unused
+// addresses, all addresses up to the last used one are kept in the wallet, the used ones
+// are flagged as used. gap <= 0 is DefaultGapLimit.
+func Restore(tp, lable, seed, passphrase, passwd string, gap int, used UsageChecker) (Walleter, error) {
+	if seed == "" {
+		return nil, errors.New("seed is required to restore wallet")
+	}
+	if used == nil {
+		return nil, errors.New("usage checker not set")
+	}
+	if gap <= 0 {
+		gap = DefaultGapLimit
+	}
+
+	seed, err := NormalizeMnemonic(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	num, usedAddrs, err := discover(tp, seed, passphrase, gap, used)
+	if err != nil {
+		return nil, err
+	}
+	wlt, err := newWallet(tp, lable, seed, passphrase, passwd, "", num)
+	if err != nil {
+		return nil, err
+	}
+	if len(usedAddrs) == 0 {
+		return wlt, nil
+	}
+	if err := SetAddressesUsed(wlt.GetID(), usedAddrs); err != nil {
+		return nil, err
+	}
+	for addr := range usedAddrs {
+		if err := wlt.SetAddressUsed(addr, true); err != nil {
+			return nil, err
+		}
+	}
+	return wlt, nil
+}
+
+// discover returns the number of addresses up to the last used one, at least 1,
+// and the used addresses among them.
+func discover(tp, seed, passphrase string, gap int, used UsageChecker) (int, map[string]bool, error) {
+	newWlt, ok := gWalletCreators[tp]
+	if !ok {
+		return 0, nil, fmt.Errorf("%s wallet not regestered", tp)
+	}
+
+	// the scratch wallet derives the addresses and is never stored.
+	wlt := newWlt()
+	wlt.SetConstant()
+	wlt.SetSeed(seed)
+	wlt.SetPassphrase(passphrase)
+	defer wlt.Erase()
+
+	num, lastUsed := 0, -1
+	usedAddrs := map[string]bool{}
+	for num-lastUsed-1 < gap {
+		entries, err := wlt.NewAddresses(gap)
+		if err != nil {
+			return 0, nil, err
+		}
+		addrs := make([]string, len(entries))
+		for i, e := range entries {
+			addrs[i] = e.Address
+		}
+		usage, err := used(addrs)
+		if err != nil {
+			return 0, nil, err
+		}
+		for i, addr := range addrs {
+			if usage[addr] {
+				lastUsed = num + i
+				usedAddrs[addr] = true
+			}
+		}
+		num += len(addrs)
+	}
+	if lastUsed < 0 {
+		// no address used, keep the default one.
+		return 1, nil, nil
+	}
+	return lastUsed + 1, usedAddrs, nil
+}
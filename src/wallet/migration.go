@@ -0,0 +1,156 @@
+package wallet
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Migration upgrades the wallet file from version From to version To,
+// Migrate changes the decoded json fields of the wallet file in place.
+type Migration struct {
+	From    string
+	To      string
+	Migrate func(fields map[string]interface{}) error
+}
+
+// gMigrations key is the version migrated from.
+var gMigrations = make(map[string]Migration)
+
+// RegisterMigration register the migration of wallet file version,
+// every version can only be migrated to one next version.
+func RegisterMigration(m Migration) error {
+	if _, ok := gMigrations[m.From]; ok {
+		return fmt.Errorf("migration of version %s already registered", m.From)
+	}
+	gMigrations[m.From] = m
+	return nil
+}
+
+func init() {
+	// 0.2 records the cipher and kdf parameters in crypto field, secrets of 0.1
+	// without crypto field are decrypted by the legacy cipher, nothing to change.
+	RegisterMigration(Migration{
+		From:    "0.1",
+		To:      "0.2",
+		Migrate: func(fields map[string]interface{}) error { return nil },
+	})
+	// 0.3 adds the mac of public fields, which needs the password, the wallet is
+	// flagged and the mac is added on next unlock. Wallets of 0.3 and later without
+	// the flag must have the mac.
+	RegisterMigration(Migration{
+		From:    "0.2",
+		To:      "0.3",
+		Migrate: migrateMacPending,
+	})
+	// 0.4 adds the metadata of address entries, which isn't covered by the mac.
+	RegisterMigration(Migration{
+		From:    "0.3",
+		To:      "0.4",
+		Migrate: migrateEntriesMeta,
+	})
+	// 0.5 leaves the lable out of the mac, so it's changed without the password. The
+	// mac can't be renewed without the password, the lable it covers is kept aside
+	// until the mac is renewed on next unlock.
+	RegisterMigration(Migration{
+		From:    "0.4",
+		To:      "0.5",
+		Migrate: migrateMacLable,
+	})
+}
+
+// migrateMacPending flags the wallet saved before the mac, its secrets have no mac key.
+func migrateMacPending(fields map[string]interface{}) error {
+	fields["mac_pending"] = true
+	return nil
+}
+
+// migrateEntriesMeta sets the derivation index and created time of entries, the addresses
+// of deterministic wallet are derived in order, the created time is the wallet time.
+func migrateEntriesMeta(fields map[string]interface{}) error {
+	entries, _ := fields["entries"].([]interface{})
+	wltType, _ := fields["wallet_type"].(string)
+	created, _ := strconv.ParseInt(fmt.Sprint(fields["tm"]), 10, 64)
+	for i, v := range entries {
+		e, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("invalid entry %d", i)
+		}
+		index := i
+		if wltType == CollectionWalletType || wltType == WatchWalletType {
+			index = -1
+		}
+		e["index"] = index
+		e["created"] = created
+		e["used"] = false
+	}
+	return nil
+}
+
+// migrateMacLable keeps the lable covered by the mac, the wallet without the mac has no
+// lable to keep.
+func migrateMacLable(fields map[string]interface{}) error {
+	if mac, _ := fields["mac"].(string); mac == "" {
+		return nil
+	}
+	lable, _ := fields["lable"].(string)
+	fields["mac_lable"] = lable
+	return nil
+}
+
+// migrate runs the migrations until the wallet file reaches WalletVersion,
+// returns the migrated file and the original version.
+func migrate(d []byte) ([]byte, string, error) {
+	fields := make(map[string]interface{})
+	dec := json.NewDecoder(bytes.NewReader(d))
+	dec.UseNumber()
+	if err := dec.Decode(&fields); err != nil {
+		return nil, "", err
+	}
+
+	version, _ := fields[metaVersion].(string)
+	if version == WalletVersion {
+		return d, version, nil
+	}
+
+	v := version
+	// each migration runs once at most, avoid looping forever
+	for i := 0; v != WalletVersion; i++ {
+		m, ok := gMigrations[v]
+		if !ok || i >= len(gMigrations) {
+			return nil, version, fmt.Errorf("wallet version %s not supported", version)
+		}
+		if err := m.Migrate(fields); err != nil {
+			return nil, version, fmt.Errorf("migrate wallet from %s to %s failed: %v", m.From, m.To, err)
+		}
+		v = m.To
+		fields[metaVersion] = v
+	}
+
+	nd, err := json.MarshalIndent(fields, "", "    ")
+	if err != nil {
+		return nil, version, err
+	}
+	return nd, version, nil
+}
+
+// migrateFile migrates the wallet file to WalletVersion and writes it back,
+// the original file is kept as $id.wlt.$version.bak.
+func migrateFile(s Storage, name string, d []byte) ([]byte, error) {
+	nd, version, err := migrate(d)
+	if err != nil {
+		return nil, err
+	}
+	if version == WalletVersion {
+		return nd, nil
+	}
+
+	if err := s.Write(fmt.Sprintf("%s.%s.bak", name, version), d); err != nil {
+		return nil, err
+	}
+	if err := s.Write(name, nd); err != nil {
+		return nil, err
+	}
+	return nd, nil
+}
@@ -0,0 +1,184 @@
+package wallet
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tyler-smith/go-bip39/wordlists"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Languages of the bip39 wordlists.
+const (
+	LangEnglish            = "english"
+	LangChineseSimplified  = "chinese_simplified"
+	LangChineseTraditional = "chinese_traditional"
+	LangJapanese           = "japanese"
+	LangSpanish            = "spanish"
+	LangFrench             = "french"
+	LangItalian            = "italian"
+	LangKorean             = "korean"
+)
+
+// languages is the order of detecting the mnemonic language.
+var languages = []string{
+	LangEnglish,
+	LangChineseSimplified,
+	LangChineseTraditional,
+	LangJapanese,
+	LangSpanish,
+	LangFrench,
+	LangItalian,
+	LangKorean,
+}
+
+var wordLists = map[string][]string{
+	LangEnglish:            wordlists.English,
+	LangChineseSimplified:  wordlists.ChineseSimplified,
+	LangChineseTraditional: wordlists.ChineseTraditional,
+	LangJapanese:           wordlists.Japanese,
+	LangSpanish:            wordlists.Spanish,
+	LangFrench:             wordlists.French,
+	LangItalian:            wordlists.Italian,
+	LangKorean:             wordlists.Korean,
+}
+
+// wordIndexes key is language, value maps the NFKD normalized word to its index in the wordlist.
+var wordIndexes = make(map[string]map[string]int)
+
+func init() {
+	for lang, list := range wordLists {
+		m := make(map[string]int, len(list))
+		for i, w := range list {
+			m[norm.NFKD.String(w)] = i
+		}
+		wordIndexes[lang] = m
+	}
+}
+
+// NewSeedWithOptions generates bip39 mnemonic of entropyBits in the wordlist of language,
+// entropyBits can be 128, 160, 192, 224 or 256, which makes 12 to 24 words.
+// The words are separated by single space, empty language is english.
+func NewSeedWithOptions(entropyBits int, language string) (string, error) {
+	if language == "" {
+		language = LangEnglish
+	}
+	list, ok := wordLists[language]
+	if !ok {
+		return "", fmt.Errorf("%s wordlist not supported", language)
+	}
+	if entropyBits < 128 || entropyBits > 256 || entropyBits%32 != 0 {
+		return "", errors.New("entropy bits must be 128, 160, 192, 224 or 256")
+	}
+
+	entropy := make([]byte, entropyBits/8)
+	if _, err := rand.Read(entropy); err != nil {
+		return "", err
+	}
+
+	idxs := mnemonicIndexes(entropy)
+	words := make([]string, len(idxs))
+	for i, idx := range idxs {
+		words[i] = list[idx]
+	}
+	return strings.Join(words, " "), nil
+}
+
+// ValidateMnemonic checks the seed is bip39 mnemonic, the language of the wordlist is
+// detected by the words. The error tells which word is invalid or that the checksum failed.
+func ValidateMnemonic(seed string) error {
+	_, err := MnemonicLanguage(seed)
+	return err
+}
+
+// MnemonicLanguage returns the wordlist language of the bip39 mnemonic, the words are
+// NFKD normalized as bip39 requires, so the composed and decomposed accents are the same word.
+func MnemonicLanguage(seed string) (string, error) {
+	words := strings.Fields(norm.NFKD.String(seed))
+	switch len(words) {
+	case 12, 15, 18, 21, 24:
+	default:
+		return "", fmt.Errorf("invalid mnemonic, %d words, must be 12, 15, 18, 21 or 24 words", len(words))
+	}
+
+	var (
+		checksumFailed bool
+		best           string
+		bestMatched    = -1
+	)
+	for _, lang := range languages {
+		idxs, matched := wordsIndexes(lang, words)
+		if matched == len(words) {
+			if checkMnemonic(idxs) {
+				return lang, nil
+			}
+			checksumFailed = true
+		}
+		if matched > bestMatched {
+			best, bestMatched = lang, matched
+		}
+	}
+	if checksumFailed {
+		return "", errors.New("invalid mnemonic, checksum failed")
+	}
+
+	// the invalid word of the language which matches most words.
+	for i, w := range words {
+		if _, ok := wordIndexes[best][w]; !ok {
+			return "", fmt.Errorf("invalid mnemonic word %d: %s", i+1, w)
+		}
+	}
+	return "", errors.New("invalid mnemonic")
+}
+
+// wordsIndexes returns the indexes of words in the wordlist and the number of words found.
+func wordsIndexes(lang string, words []string) ([]int, int) {
+	idxs := make([]int, len(words))
+	matched := 0
+	for i, w := range words {
+		idx, ok := wordIndexes[lang][w]
+		if !ok {
+			idx = -1
+		} else {
+			matched++
+		}
+		idxs[i] = idx
+	}
+	return idxs, matched
+}
+
+// mnemonicIndexes splits the entropy and its checksum into 11 bits word indexes.
+func mnemonicIndexes(entropy []byte) []int {
+	checksum := sha256.Sum256(entropy)
+	data := append(append([]byte{}, entropy...), checksum[0])
+	n := (len(entropy)*8 + len(entropy)/4) / 11
+	idxs := make([]int, n)
+	for i := range idxs {
+		for b := i * 11; b < (i+1)*11; b++ {
+			idxs[i] = idxs[i]<<1 | int(data[b/8]>>(7-uint(b%8))&1)
+		}
+	}
+	return idxs
+}
+
+// checkMnemonic checks the checksum in the word indexes.
+func checkMnemonic(idxs []int) bool {
+	bits := len(idxs) * 11
+	csBits := bits / 33
+	data := make([]byte, (bits+7)/8)
+	for i, idx := range idxs {
+		for j := 0; j < 11; j++ {
+			if idx>>(10-uint(j))&1 == 1 {
+				b := i*11 + j
+				data[b/8] |= 1 << (7 - uint(b%8))
+			}
+		}
+	}
+	entropy := data[:(bits-csBits)/8]
+	checksum := sha256.Sum256(entropy)
+	mask := byte(0xff) << (8 - uint(csBits))
+	return data[len(entropy)]&mask == checksum[0]&mask
+}
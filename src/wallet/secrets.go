@@ -0,0 +1,209 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sort"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/MDLlife/wallet-api/src/util/secure"
+)
+
+// The secrets are encoded as json object of strings, encoding/json works on
+// immutable strings and buffers which can't be wiped, so the values are
+// quoted and unquoted by hand into buffers allocated by secure.Bytes.
+
+var errInvalidSecrets = errors.New("invalid secrets")
+
+// marshalSecrets encodes the secrets of all maps into one json object,
+// the caller wipes the returned buffer.
+func marshalSecrets(ms ...map[string][]byte) ([]byte, error) {
+	keys := []string{}
+	values := make(map[string][]byte)
+	size := 2
+	for _, m := range ms {
+		for k, v := range m {
+			if _, ok := values[k]; ok {
+				return nil, errors.New("duplicate secret " + k)
+			}
+			keys = append(keys, k)
+			values[k] = v
+			// the key is quoted at most 6 bytes per byte, plus quotes, colon and comma.
+			size += 6*(len(k)+len(v)) + 6
+		}
+	}
+	sort.Strings(keys)
+
+	b := secure.Bytes(size)[:0]
+	b = append(b, '{')
+	for i, k := range keys {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = appendQuoted(b, []byte(k))
+		b = append(b, ':')
+		b = appendQuoted(b, values[k])
+	}
+	b = append(b, '}')
+	return b, nil
+}
+
+// appendQuoted appends v as json string, dst must have enough capacity
+// or the secret would be copied to new buffer.
+func appendQuoted(dst, v []byte) []byte {
+	const hexDigits = "0123456789abcdef"
+	dst = append(dst, '"')
+	for _, c := range v {
+		switch {
+		case c == '"' || c == '\\':
+			dst = append(dst, '\\', c)
+		case c < 0x20:
+			dst = append(dst, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+		default:
+			dst = append(dst, c)
+		}
+	}
+	return append(dst, '"')
+}
+
+// unmarshalSecrets decodes the json object of strings, the caller wipes the values.
+func unmarshalSecrets(d []byte) (map[string][]byte, error) {
+	raw := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(d, &raw); err != nil {
+		return nil, err
+	}
+
+	secrets := make(map[string][]byte, len(raw))
+	var err error
+	for k, v := range raw {
+		if err == nil {
+			secrets[k], err = unquote(v)
+		}
+		secure.Wipe(v)
+	}
+	if err != nil {
+		wipeSecrets(secrets)
+		return nil, err
+	}
+	return secrets, nil
+}
+
+// unquote decodes the json string into buffer allocated by secure.Bytes.
+func unquote(q []byte) ([]byte, error) {
+	if len(q) < 2 || q[0] != '"' || q[len(q)-1] != '"' {
+		return nil, errInvalidSecrets
+	}
+	q = q[1 : len(q)-1]
+	b := secure.Bytes(len(q))
+	n := 0
+	for i := 0; i < len(q); {
+		c := q[i]
+		if c != '\\' {
+			b[n] = c
+			n++
+			i++
+			continue
+		}
+		if i+1 >= len(q) {
+			secure.Wipe(b)
+			return nil, errInvalidSecrets
+		}
+		switch q[i+1] {
+		case '"', '\\', '/':
+			b[n] = q[i+1]
+		case 'b':
+			b[n] = '\b'
+		case 'f':
+			b[n] = '\f'
+		case 'n':
+			b[n] = '\n'
+		case 'r':
+			b[n] = '\r'
+		case 't':
+			b[n] = '\t'
+		case 'u':
+			r, l := unquoteRune(q[i:])
+			if l == 0 {
+				secure.Wipe(b)
+				return nil, errInvalidSecrets
+			}
+			// the escape is at least as long as the utf8 encoding.
+			n += utf8.EncodeRune(b[n:], r)
+			i += l
+			continue
+		default:
+			secure.Wipe(b)
+			return nil, errInvalidSecrets
+		}
+		n++
+		i += 2
+	}
+	return b[:n], nil
+}
+
+// unquoteRune decodes \uXXXX escape and the surrogate pair,
+// returns the length of escapes, 0 if invalid.
+func unquoteRune(q []byte) (rune, int) {
+	r := hexRune(q)
+	if r < 0 {
+		return 0, 0
+	}
+	if !utf16.IsSurrogate(r) {
+		return r, 6
+	}
+	if r2 := hexRune(q[6:]); r2 >= 0 {
+		if dr := utf16.DecodeRune(r, r2); dr != utf8.RuneError {
+			return dr, 12
+		}
+	}
+	return utf8.RuneError, 6
+}
+
+// hexRune decodes \uXXXX, returns -1 if invalid.
+func hexRune(q []byte) rune {
+	if len(q) < 6 || q[0] != '\\' || q[1] != 'u' {
+		return -1
+	}
+	var r rune
+	for _, c := range q[2:6] {
+		switch {
+		case '0' <= c && c <= '9':
+			c = c - '0'
+		case 'a' <= c && c <= 'f':
+			c = c - 'a' + 10
+		case 'A' <= c && c <= 'F':
+			c = c - 'A' + 10
+		default:
+			return -1
+		}
+		r = r*16 + rune(c)
+	}
+	return r
+}
+
+// wipeSecrets wipes and deletes all values of the map.
+func wipeSecrets(m map[string][]byte) {
+	for k, v := range m {
+		secure.Wipe(v)
+		delete(m, k)
+	}
+}
+
+// hexBytes hex encodes the secret into buffer allocated by secure.Bytes.
+func hexBytes(v []byte) []byte {
+	b := secure.Bytes(hex.EncodedLen(len(v)))
+	hex.Encode(b, v)
+	return b
+}
+
+// unhexBytes hex decodes the secret into buffer allocated by secure.Bytes.
+func unhexBytes(v []byte) ([]byte, error) {
+	b := secure.Bytes(hex.DecodedLen(len(v)))
+	if _, err := hex.Decode(b, v); err != nil {
+		secure.Wipe(b)
+		return nil, err
+	}
+	return b, nil
+}
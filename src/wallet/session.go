@@ -0,0 +1,105 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// session keeps the wallet unlocked until it expires or is locked, the wallet keeps the
+// decrypted secrets and the key derived from the password, the password isn't kept.
+type session struct {
+	timer *time.Timer
+}
+
+// close stops the timer.
+func (s *session) close() {
+	s.timer.Stop()
+}
+
+// unlock decrypts the wallet and keeps the secrets for ttl, unlock again extends the ttl.
+func (wlts *wallets) unlock(id string, passwd []byte, ttl time.Duration) error {
+	if ttl <= 0 {
+		return errors.New("invalid unlock ttl")
+	}
+
+	wlts.mtx.Lock()
+	defer wlts.mtx.Unlock()
+	wlt, ok := wlts.Value[id]
+	if !ok {
+		return fmt.Errorf("%s wallet does not exist", id)
+	}
+	passwd, err := wlts.sessionPassword(wlt, passwd)
+	if err != nil {
+		return err
+	}
+	if err := wlt.Unlock(passwd); err != nil {
+		return err
+	}
+	wlts.rewrap(wlt, passwd)
+
+	s := &session{}
+	if old, ok := wlts.sessions[id]; ok {
+		old.close()
+	}
+	s.timer = time.AfterFunc(ttl, func() { wlts.expire(id, s) })
+	wlts.sessions[id] = s
+	return nil
+}
+
+func (wlts *wallets) lock(id string) error {
+	wlts.mtx.Lock()
+	defer wlts.mtx.Unlock()
+	if _, ok := wlts.Value[id]; !ok {
+		return fmt.Errorf("%s wallet does not exist", id)
+	}
+	wlts.lockWallet(id)
+	return nil
+}
+
+// expire locks the wallet if s is still its session.
+func (wlts *wallets) expire(id string, s *session) {
+	wlts.mtx.Lock()
+	defer wlts.mtx.Unlock()
+	if wlts.sessions[id] == s {
+		wlts.lockWallet(id)
+	}
+}
+
+// lockWallet closes the session and erases the secrets, mtx must be held.
+func (wlts *wallets) lockWallet(id string) {
+	if s, ok := wlts.sessions[id]; ok {
+		s.close()
+		delete(wlts.sessions, id)
+	}
+	if wlt, ok := wlts.Value[id]; ok {
+		wlt.Lock()
+	}
+}
+
+// isUnlocked checks the wallet has an unexpired session.
+func (wlts *wallets) isUnlocked(id string) bool {
+	wlts.mtx.Lock()
+	defer wlts.mtx.Unlock()
+	_, ok := wlts.sessions[id]
+	return ok
+}
+
+// password verifies passwd by decrypting, the unlocked wallet accepts empty password,
+// which makes the wallet encrypted by the key kept in it. Returns passwd.
+func (wlts *wallets) password(wlt Walleter, passwd []byte) ([]byte, error) {
+	if _, ok := wlts.sessions[wlt.GetID()]; ok && len(passwd) == 0 {
+		return passwd, nil
+	}
+	return passwd, checkPassword(wlt, passwd)
+}
+
+// sessionPassword returns passwd unverified if the wallet is locked, the decryption of the
+// operation verifies it, so the kdf runs once. The unlocked wallet isn't decrypted again,
+// so passwd other than empty is verified here.
+func (wlts *wallets) sessionPassword(wlt Walleter, passwd []byte) ([]byte, error) {
+	if _, ok := wlts.sessions[wlt.GetID()]; ok {
+		return wlts.password(wlt, passwd)
+	}
+	return passwd, nil
+}
@@ -0,0 +1,316 @@
+package wallet
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/MDLlife/wallet-api/src/util/secure"
+)
+
+// The shares follow the shamir's secret sharing of SLIP-39: GF(256) of the polynomial
+// x^8 + x^4 + x^3 + x + 1, the secret at x = 255 and its digest at x = 254, which checks
+// the recovered secret without revealing anything about it by less than threshold shares.
+// The shares are encoded in hex instead of the SLIP-39 mnemonic, the secret isn't
+// encrypted by passphrase, and there are no groups.
+
+const (
+	shareVersion    = 1
+	shareHeaderLen  = 6 // version, identifier (2 bytes), threshold, index, secret length
+	shareChecksum   = 4
+	maxShareCount   = 16
+	secretIndex     = 255
+	digestIndex     = 254
+	digestLen       = 4
+	minSecretLength = 16
+)
+
+var (
+	gfExp [255]byte
+	gfLog [256]byte
+)
+
+func init() {
+	// 3 is the generator of GF(256) of the rijndael polynomial.
+	x := 1
+	for i := 0; i < 255; i++ {
+		gfExp[i] = byte(x)
+		gfLog[x] = byte(i)
+		x ^= x << 1
+		if x&0x100 != 0 {
+			x ^= 0x11b
+		}
+	}
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[(int(gfLog[a])+int(gfLog[b]))%255]
+}
+
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gfExp[(int(gfLog[a])+255-int(gfLog[b]))%255]
+}
+
+// share is the decoded share.
+type share struct {
+	ID        uint16
+	Threshold int
+	Index     byte
+	Value     []byte
+}
+
+// interpolate returns the value at x of the polynomial through the points.
+func interpolate(xs []byte, ys [][]byte, x byte) []byte {
+	res := secure.Bytes(len(ys[0]))
+	for i := range xs {
+		// lagrange basis polynomial at x, subtraction is xor in GF(256).
+		basis := byte(1)
+		for j := range xs {
+			if i != j {
+				basis = gfMul(basis, gfDiv(x^xs[j], xs[i]^xs[j]))
+			}
+		}
+		for k := range res {
+			res[k] ^= gfMul(basis, ys[i][k])
+		}
+	}
+	return res
+}
+
+// padSecret prefixes the length and pads the secret to 16 bytes at least,
+// so short brain seeds leave enough room for the digest.
+func padSecret(secret []byte) []byte {
+	n := len(secret) + 1
+	if n < minSecretLength {
+		n = minSecretLength
+	}
+	p := secure.Bytes(n)
+	p[0] = byte(len(secret))
+	copy(p[1:], secret)
+	return p
+}
+
+func unpadSecret(p []byte) ([]byte, error) {
+	if len(p) == 0 || int(p[0]) > len(p)-1 {
+		return nil, errors.New("invalid secret padding")
+	}
+	return secure.Copy(p[1 : 1+int(p[0])]), nil
+}
+
+// SplitSecret splits the secret into count shares, any threshold of them recover the secret.
+// The secret is 254 bytes at most, the shares are hex strings with checksum.
+func SplitSecret(secret []byte, threshold, count int) ([]string, error) {
+	if len(secret) == 0 || len(secret) > 254 {
+		return nil, errors.New("secret length must be 1 to 254 bytes")
+	}
+	if count < 1 || count > maxShareCount {
+		return nil, fmt.Errorf("share count must be 1 to %d", maxShareCount)
+	}
+	if threshold < 1 || threshold > count {
+		return nil, errors.New("threshold must be 1 to share count")
+	}
+
+	var id [2]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, err
+	}
+	s := padSecret(secret)
+	defer secure.Wipe(s)
+
+	values := make([][]byte, count)
+	defer func() {
+		for _, v := range values {
+			secure.Wipe(v)
+		}
+	}()
+	if threshold == 1 {
+		for i := range values {
+			values[i] = secure.Copy(s)
+		}
+	} else {
+		// threshold-2 random shares, the digest and the secret determine the polynomial.
+		xs := make([]byte, 0, threshold)
+		ys := make([][]byte, 0, threshold)
+		for i := 0; i < threshold-2; i++ {
+			values[i] = secure.Bytes(len(s))
+			if _, err := rand.Read(values[i]); err != nil {
+				return nil, err
+			}
+			xs = append(xs, byte(i))
+			ys = append(ys, values[i])
+		}
+		r := secure.Bytes(len(s) - digestLen)
+		defer secure.Wipe(r)
+		if _, err := rand.Read(r); err != nil {
+			return nil, err
+		}
+		digest := append(secretDigest(r, s), r...)
+		defer secure.Wipe(digest)
+		xs = append(xs, digestIndex, secretIndex)
+		ys = append(ys, digest, s)
+		for i := threshold - 2; i < count; i++ {
+			values[i] = interpolate(xs, ys, byte(i))
+		}
+	}
+
+	shares := make([]string, count)
+	for i, v := range values {
+		shares[i] = encodeShare(share{
+			ID:        binary.BigEndian.Uint16(id[:]),
+			Threshold: threshold,
+			Index:     byte(i),
+			Value:     v,
+		})
+	}
+	return shares, nil
+}
+
+// CombineShares recovers the secret from threshold shares at least, the shares must be of one
+// split, duplicate or mistyped shares are rejected, the digest checks the recovered secret.
+func CombineShares(shares []string) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, errors.New("no share")
+	}
+	decoded := make([]share, len(shares))
+	for i, s := range shares {
+		sh, err := decodeShare(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid share %d: %v", i+1, err)
+		}
+		decoded[i] = sh
+	}
+
+	first := decoded[0]
+	xs := make([]byte, 0, len(decoded))
+	ys := make([][]byte, 0, len(decoded))
+	seen := make(map[byte]bool)
+	for i, sh := range decoded {
+		if sh.ID != first.ID || sh.Threshold != first.Threshold || len(sh.Value) != len(first.Value) {
+			return nil, fmt.Errorf("share %d doesn't belong to the same secret", i+1)
+		}
+		if seen[sh.Index] {
+			return nil, fmt.Errorf("duplicate share %d", i+1)
+		}
+		seen[sh.Index] = true
+		xs = append(xs, sh.Index)
+		ys = append(ys, sh.Value)
+	}
+	if len(decoded) < first.Threshold {
+		return nil, fmt.Errorf("need %d shares, got %d", first.Threshold, len(decoded))
+	}
+	// more shares than threshold are not needed.
+	xs, ys = xs[:first.Threshold], ys[:first.Threshold]
+
+	if first.Threshold == 1 {
+		return unpadSecret(ys[0])
+	}
+	s := interpolate(xs, ys, secretIndex)
+	defer secure.Wipe(s)
+	digest := interpolate(xs, ys, digestIndex)
+	defer secure.Wipe(digest)
+	if !hmac.Equal(digest[:digestLen], secretDigest(digest[digestLen:], s)) {
+		return nil, errors.New("invalid shares, the digest of secret does not match")
+	}
+	return unpadSecret(s)
+}
+
+func secretDigest(r, s []byte) []byte {
+	h := hmac.New(sha256.New, r)
+	h.Write(s)
+	return h.Sum(nil)[:digestLen]
+}
+
+// encodeShare encodes the share as hex of version, identifier, threshold, index,
+// secret length, value and checksum.
+func encodeShare(sh share) string {
+	d := make([]byte, 0, shareHeaderLen+len(sh.Value)+shareChecksum)
+	d = append(d, shareVersion, byte(sh.ID>>8), byte(sh.ID), byte(sh.Threshold), sh.Index, byte(len(sh.Value)))
+	d = append(d, sh.Value...)
+	sum := sha256.Sum256(d)
+	d = append(d, sum[:shareChecksum]...)
+	s := hex.EncodeToString(d)
+	secure.Wipe(d)
+	return s
+}
+
+func decodeShare(s string) (share, error) {
+	d, err := hex.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return share{}, errors.New("not hex")
+	}
+	if len(d) < shareHeaderLen+shareChecksum || int(d[5]) != len(d)-shareHeaderLen-shareChecksum {
+		return share{}, errors.New("invalid length")
+	}
+	n := len(d) - shareChecksum
+	sum := sha256.Sum256(d[:n])
+	if !hmac.Equal(sum[:shareChecksum], d[n:]) {
+		return share{}, errors.New("checksum failed")
+	}
+	if d[0] != shareVersion {
+		return share{}, fmt.Errorf("version %d not supported", d[0])
+	}
+	if d[3] == 0 || int(d[3]) > maxShareCount {
+		return share{}, errors.New("invalid threshold")
+	}
+	return share{
+		ID:        binary.BigEndian.Uint16(d[1:3]),
+		Threshold: int(d[3]),
+		Index:     d[4],
+		Value:     d[shareHeaderLen:n],
+	}, nil
+}
+
+// SplitSeed splits the seed of the unlocked wallet into count shares, any threshold of
+// them restore the wallet by RestoreFromShares, less than threshold reveal nothing of the seed.
+// The passphrase isn't in the shares, it's required to restore the wallet as well.
+func SplitSeed(id string, threshold, count int) ([]string, error) {
+	return gWallets.splitSeed(id, threshold, count)
+}
+
+// RestoreFromShares restores the wallet from the shares of SplitSeed.
+func RestoreFromShares(tp, lable string, shares []string, passphrase, passwd string) (Walleter, error) {
+	secret, err := CombineShares(shares)
+	if err != nil {
+		return nil, err
+	}
+	seed := string(secret)
+	secure.Wipe(secret)
+	return newWallet(tp, lable, seed, passphrase, passwd, "", 1)
+}
+
+func (wlts *wallets) splitSeed(id string, threshold, count int) ([]string, error) {
+	wlts.mtx.Lock()
+	defer wlts.mtx.Unlock()
+	wlt, ok := wlts.Value[id]
+	if !ok {
+		return nil, fmt.Errorf("%s wallet does not exist", id)
+	}
+	if _, ok := wlts.sessions[id]; !ok {
+		return nil, errors.New("unlock the wallet to split the seed")
+	}
+	passwd, err := wlts.password(wlt, nil)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := wlt.GetSeedBytes(passwd)
+	if err != nil {
+		return nil, err
+	}
+	defer secure.Wipe(seed)
+	wlts.rewrap(wlt, passwd)
+	if len(seed) == 0 {
+		return nil, errors.New("wallet has no seed")
+	}
+	return SplitSecret(seed, threshold, count)
+}
@@ -0,0 +1,500 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Storage persists the wallet files, name is the wallet file name such as spo_xxx.wlt.
+type Storage interface {
+	List() ([]string, error)           // list names of all files.
+	Exist(name string) bool            // check the file exists or not.
+	Read(name string) ([]byte, error)  // read the file.
+	Write(name string, d []byte) error // replace the file, the old file is kept as backup.
+	Restore(name string) error         // restore the file from the backup of last write.
+	Remove(name string) error          // remove the file, its backup and the backups of migrations.
+	Discard(name string) error         // remove the backups and temp copies, the file is kept.
+}
+
+// Recovery records the wallet file recovered from its temp or backup copy.
+type Recovery struct {
+	Name string `json:"name"` // wallet file name
+	From string `json:"from"` // name of the copy recovered from
+}
+
+// Recoverer is implemented by the storage whose writes may be interrupted by crash,
+// Recover replaces each broken file by its newest valid copy.
+type Recoverer interface {
+	Recover(validate func(d []byte) error) ([]Recovery, error)
+}
+
+var errBackupNotExist = errors.New("backup does not exist")
+
+// isVersionBackup checks f is the copy of $name kept by migration as $name.$version.bak,
+// or the temp and backup copies of writing it.
+func isVersionBackup(name, f string) bool {
+	rest := strings.TrimPrefix(f, name+".")
+	return rest != f && rest != "" && rest[0] >= '0' && rest[0] <= '9' && strings.Contains(rest, ".bak")
+}
+
+// fileStorage stores wallet files in dir, the backup of $name is $name.bak.
+type fileStorage struct {
+	dir string
+}
+
+// NewFileStorage creates storage of the wallet dir.
+func NewFileStorage(dir string) Storage {
+	return &fileStorage{dir: dir}
+}
+
+func (fs *fileStorage) List() ([]string, error) {
+	fileInfos, err := ioutil.ReadDir(fs.dir)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(fileInfos))
+	for _, fileInfo := range fileInfos {
+		if !fileInfo.IsDir() {
+			names = append(names, fileInfo.Name())
+		}
+	}
+	return names, nil
+}
+
+func (fs *fileStorage) Exist(name string) bool {
+	_, err := os.Stat(filepath.Join(fs.dir, name))
+	return !os.IsNotExist(err)
+}
+
+func (fs *fileStorage) Read(name string) ([]byte, error) {
+	return ioutil.ReadFile(filepath.Join(fs.dir, name))
+}
+
+// Write writes the file to temp file, then replaces the file,
+// the old file is kept as .bak file. Files and dir are flushed to disk.
+func (fs *fileStorage) Write(name string, d []byte) error {
+	path := filepath.Join(fs.dir, name)
+	tmpPath := path + "." + "tmp"
+
+	// write wallet to temp file.
+	if err := writeFileSync(tmpPath, d); err != nil {
+		return err
+	}
+
+	// create bak file if exist.
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		if err := os.Rename(path, path+".bak"); err != nil {
+			os.Remove(tmpPath)
+			return err
+		}
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+	return syncDir(fs.dir)
+}
+
+func (fs *fileStorage) Restore(name string) error {
+	path := filepath.Join(fs.dir, name)
+	if _, err := os.Stat(path + ".bak"); os.IsNotExist(err) {
+		return errBackupNotExist
+	}
+	if err := os.Rename(path+".bak", path); err != nil {
+		return err
+	}
+	return syncDir(fs.dir)
+}
+
+func (fs *fileStorage) Remove(name string) error {
+	// the temp and backup copies are removed too, or they are recovered on next load.
+	if err := os.RemoveAll(filepath.Join(fs.dir, name)); err != nil {
+		return err
+	}
+	return fs.Discard(name)
+}
+
+// Discard removes $name.tmp, $name.bak and $name.$version.bak, so the old content
+// can't be recovered.
+func (fs *fileStorage) Discard(name string) error {
+	names, err := fs.List()
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(fs.dir, name)
+	paths := []string{path + ".tmp", path + ".bak"}
+	for _, f := range names {
+		if isVersionBackup(name, f) {
+			paths = append(paths, filepath.Join(fs.dir, f))
+		}
+	}
+	for _, p := range paths {
+		if err := os.RemoveAll(p); err != nil {
+			return err
+		}
+	}
+	return syncDir(fs.dir)
+}
+
+// Recover picks the newest valid copy of $name, $name.tmp and $name.bak left by
+// interrupted writes, a broken file is kept as $name.corrupt.
+func (fs *fileStorage) Recover(validate func(d []byte) error) ([]Recovery, error) {
+	names, err := fs.List()
+	if err != nil {
+		return nil, err
+	}
+
+	// collect wallet names, including the ones only have temp or backup copies.
+	walletNames := []string{}
+	seen := make(map[string]bool)
+	for _, name := range names {
+		for _, suffix := range []string{".tmp", ".bak"} {
+			if strings.HasSuffix(name, "."+Ext+suffix) {
+				name = strings.TrimSuffix(name, suffix)
+			}
+		}
+		if !strings.HasSuffix(name, "."+Ext) || seen[name] {
+			continue
+		}
+		seen[name] = true
+		walletNames = append(walletNames, name)
+	}
+
+	recoveries := []Recovery{}
+	for _, name := range walletNames {
+		from, err := fs.recover(name, validate)
+		if err != nil {
+			return recoveries, err
+		}
+		if from != "" {
+			recoveries = append(recoveries, Recovery{Name: name, From: from})
+		}
+	}
+	return recoveries, nil
+}
+
+// recover returns the name of the copy which the file is recovered from,
+// empty if the file is the newest valid copy.
+func (fs *fileStorage) recover(name string, validate func(d []byte) error) (string, error) {
+	path := filepath.Join(fs.dir, name)
+	var (
+		newest    string
+		newestTm  time.Time
+		fileValid bool
+	)
+	// the order is the preference when the modified time are the same,
+	// backup is only used when the file is broken.
+	for _, p := range []string{path, path + ".tmp", path + ".bak"} {
+		if p == path+".bak" && fileValid {
+			continue
+		}
+		info, err := os.Stat(p)
+		if err != nil {
+			continue
+		}
+		d, err := ioutil.ReadFile(p)
+		if err != nil || validate(d) != nil {
+			continue
+		}
+		if p == path {
+			fileValid = true
+		}
+		if newest == "" || info.ModTime().After(newestTm) {
+			newest, newestTm = p, info.ModTime()
+		}
+	}
+
+	switch newest {
+	case "":
+		// no valid copy, leave it to the loader.
+		return "", nil
+	case path:
+		// stale temp file of interrupted write.
+		if info, err := os.Stat(path + ".tmp"); err == nil && info.Mode().IsRegular() {
+			if err := os.Remove(path + ".tmp"); err != nil {
+				return "", err
+			}
+			return "", syncDir(fs.dir)
+		}
+		return "", nil
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		target := path + ".corrupt"
+		if fileValid {
+			// the valid older file becomes the backup, as the interrupted write does.
+			target = path + ".bak"
+		}
+		if err := os.Rename(path, target); err != nil {
+			return "", err
+		}
+	}
+	if err := os.Rename(newest, path); err != nil {
+		return "", err
+	}
+	if err := syncDir(fs.dir); err != nil {
+		return "", err
+	}
+	return filepath.Base(newest), nil
+}
+
+// writeFileSync writes the file and flushes it to disk.
+func writeFileSync(path string, d []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(d); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
+
+// syncDir flushes the renamed and removed entries of dir to disk.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// memoryStorage keeps wallet files in memory, used for testing.
+type memoryStorage struct {
+	mtx   sync.Mutex
+	files map[string][]byte
+	baks  map[string][]byte
+}
+
+// NewMemoryStorage creates storage which keeps wallet files in memory.
+func NewMemoryStorage() Storage {
+	return &memoryStorage{
+		files: make(map[string][]byte),
+		baks:  make(map[string][]byte),
+	}
+}
+
+func (ms *memoryStorage) List() ([]string, error) {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+	names := make([]string, 0, len(ms.files))
+	for name := range ms.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (ms *memoryStorage) Exist(name string) bool {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+	_, ok := ms.files[name]
+	return ok
+}
+
+func (ms *memoryStorage) Read(name string) ([]byte, error) {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+	d, ok := ms.files[name]
+	if !ok {
+		return nil, fmt.Errorf("%s does not exist", name)
+	}
+	return append([]byte{}, d...), nil
+}
+
+func (ms *memoryStorage) Write(name string, d []byte) error {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+	if old, ok := ms.files[name]; ok {
+		ms.baks[name] = old
+	}
+	ms.files[name] = append([]byte{}, d...)
+	return nil
+}
+
+func (ms *memoryStorage) Restore(name string) error {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+	d, ok := ms.baks[name]
+	if !ok {
+		return errBackupNotExist
+	}
+	ms.files[name] = d
+	delete(ms.baks, name)
+	return nil
+}
+
+func (ms *memoryStorage) Remove(name string) error {
+	ms.mtx.Lock()
+	delete(ms.files, name)
+	ms.mtx.Unlock()
+	return ms.Discard(name)
+}
+
+func (ms *memoryStorage) Discard(name string) error {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+	delete(ms.baks, name)
+	for _, m := range []map[string][]byte{ms.files, ms.baks} {
+		for f := range m {
+			if isVersionBackup(name, f) {
+				delete(m, f)
+			}
+		}
+	}
+	return nil
+}
+
+var (
+	filesBucket = []byte("wallets")
+	baksBucket  = []byte("backups")
+)
+
+// boltStorage keeps all wallet files in a single bolt db file.
+type boltStorage struct {
+	db *bolt.DB
+}
+
+// NewBoltStorage opens or creates the bolt db file which stores the wallets.
+func NewBoltStorage(path string) (Storage, error) {
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(filesBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(baksBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &boltStorage{db: db}, nil
+}
+
+func (bs *boltStorage) List() ([]string, error) {
+	names := []string{}
+	err := bs.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(filesBucket).ForEach(func(k, v []byte) error {
+			names = append(names, string(k))
+			return nil
+		})
+	})
+	return names, err
+}
+
+func (bs *boltStorage) Exist(name string) bool {
+	var ok bool
+	bs.db.View(func(tx *bolt.Tx) error {
+		ok = tx.Bucket(filesBucket).Get([]byte(name)) != nil
+		return nil
+	})
+	return ok
+}
+
+func (bs *boltStorage) Read(name string) ([]byte, error) {
+	var d []byte
+	err := bs.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(filesBucket).Get([]byte(name))
+		if v == nil {
+			return fmt.Errorf("%s does not exist", name)
+		}
+		// the value is only valid in the transaction.
+		d = append([]byte{}, v...)
+		return nil
+	})
+	return d, err
+}
+
+// Write replaces the file and keeps the backup in one transaction.
+func (bs *boltStorage) Write(name string, d []byte) error {
+	return bs.db.Update(func(tx *bolt.Tx) error {
+		files := tx.Bucket(filesBucket)
+		if old := files.Get([]byte(name)); old != nil {
+			if err := tx.Bucket(baksBucket).Put([]byte(name), append([]byte{}, old...)); err != nil {
+				return err
+			}
+		}
+		return files.Put([]byte(name), d)
+	})
+}
+
+func (bs *boltStorage) Restore(name string) error {
+	return bs.db.Update(func(tx *bolt.Tx) error {
+		baks := tx.Bucket(baksBucket)
+		d := baks.Get([]byte(name))
+		if d == nil {
+			return errBackupNotExist
+		}
+		if err := tx.Bucket(filesBucket).Put([]byte(name), append([]byte{}, d...)); err != nil {
+			return err
+		}
+		return baks.Delete([]byte(name))
+	})
+}
+
+func (bs *boltStorage) Remove(name string) error {
+	return bs.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(filesBucket).Delete([]byte(name)); err != nil {
+			return err
+		}
+		return discard(tx, name)
+	})
+}
+
+func (bs *boltStorage) Discard(name string) error {
+	return bs.db.Update(func(tx *bolt.Tx) error {
+		return discard(tx, name)
+	})
+}
+
+// discard deletes the backup of name and the backups of migrations in the transaction.
+func discard(tx *bolt.Tx, name string) error {
+	if err := tx.Bucket(baksBucket).Delete([]byte(name)); err != nil {
+		return err
+	}
+	for _, bucket := range [][]byte{filesBucket, baksBucket} {
+		b := tx.Bucket(bucket)
+		// keys are collected first, the bucket can't be changed while iterating.
+		keys := [][]byte{}
+		if err := b.ForEach(func(k, v []byte) error {
+			if isVersionBackup(name, string(k)) {
+				keys = append(keys, append([]byte{}, k...))
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, k := range keys {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Close closes the bolt db.
+func (bs *boltStorage) Close() error {
+	return bs.db.Close()
+}
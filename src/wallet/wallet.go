@@ -1,51 +1,95 @@
 package wallet
 
 import (
+	"crypto/rand"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/skycoin/skycoin/src/util/file"
-	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/MDLlife/wallet-api/src/coin"
-	bip39 "github.com/tyler-smith/go-bip39"
+	"github.com/MDLlife/wallet-api/src/util/encrypt"
+	"github.com/MDLlife/wallet-api/src/util/secure"
+	logging "github.com/op/go-logging"
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/util/file"
+	"golang.org/x/text/unicode/norm"
 )
 
 // Walleter interface, new wallet type can be supported if it fullfills this interface.
 type Walleter interface {
-	GetID() string                                          // get wallet id.
-	SetID(id string)                                        // set wallet id.
-	SetSeed(seed string)                                    // init the wallet seed.
-	SetLable(lable string)                                  // set the wallet lable.
-	SetConstant()                                           // set constant such as version, type
-	GetType() string                                        // get the wallet coin type.
-	SetTime(tm string)                                      // set the wallet created time.
-	GetSeed(passwd string) string                           // get the wallet seed.
-	Validate() error                                        // Validate wallet fields
-	IsPasswordCorrect(passwd string) error                  // check password correct or not.
-	Decryption(passwd string) error                         // decryption secrets for new address.
-	Encryption(passwd string) error                         // encryption seed and private key
-	NewAddresses(num int) ([]coin.AddressEntry, error)      // generate new addresses.
-	GetAddresses() []string                                 // get all addresses in the wallet.
-	GetKeypair(addr, passwd string) (string, string, error) // get pub/sec key pair of specific address
-	Save(w io.Writer, passwd string) error                  // save the wallet.
-	Load(r io.Reader, passwd string) error                  // load wallet from reader.
-	Copy() Walleter                                         // copy of self, for thread safe.
+	GetID() string                                                 // get wallet id.
+	SetID(id string)                                               // set wallet id.
+	SetSeed(seed string)                                           // init the wallet seed.
+	SetPassphrase(passphrase string)                               // set the bip39 passphrase of the seed.
+	SetLable(lable string)                                         // set the wallet lable.
+	SetConstant()                                                  // set constant such as version, type
+	SetWalletType(wltType string)                                  // set the wallet type, deterministic or collection.
+	GetType() string                                               // get the wallet coin type.
+	GetLable() string                                              // get the wallet lable.
+	SetGroup(group string)                                         // set the group id of linked wallets.
+	GetGroup() string                                              // get the group id, empty if not linked.
+	GetTime() string                                               // get the wallet created time.
+	GetVersion() string                                            // get the wallet file version.
+	GetWalletType() string                                         // get the wallet type, deterministic, collection or watch-only.
+	IsMacPending() bool                                            // saved before the mac, the mac is added on next unlock.
+	IsVerified() bool                                              // public fields are verified by the mac since loaded.
+	SetTime(tm string)                                             // set the wallet created time.
+	GetSeed(passwd []byte) (string, error)                         // get the wallet seed.
+	GetSeedBytes(passwd []byte) ([]byte, error)                    // get the wallet seed, the caller wipes it.
+	Validate() error                                               // Validate wallet fields
+	IsPasswordCorrect(passwd []byte) error                         // check password correct or not.
+	Decryption(passwd []byte) error                                // decryption secrets for new address.
+	Encryption(passwd []byte) error                                // encryption seed and private key
+	Erase()                                                        // erase the decrypted secrets.
+	Unlock(passwd []byte) error                                    // decrypt and keep the secrets until Lock.
+	Lock()                                                         // erase the secrets kept by Unlock.
+	NewAddresses(num int) ([]coin.AddressEntry, error)             // generate new addresses.
+	ImportKey(seckey []byte) (coin.AddressEntry, error)            // import the secret key into collection wallet.
+	GetAddresses() []string                                        // get all addresses in the wallet.
+	GetAddressEntries() []coin.AddressEntry                        // get the address entries with metadata.
+	SetAddressLabel(addr, label string) error                      // set the label of address.
+	SetAddressUsed(addr string, used bool) error                   // set whether the address has received coins.
+	GetKeypair(addr string, passwd []byte) (string, string, error) // get pub/sec key pair of specific address
+	GetSeckey(addr string, passwd []byte) ([]byte, error)          // get the secret key of specific address, the caller wipes it.
+	GetSeckeys(addrs []string, passwd []byte) ([][]byte, error)    // get the secret keys of addresses by one decryption, the caller wipes them.
+	Save(w io.Writer, passwd []byte) error                         // save the wallet.
+	SaveCopy(w io.Writer, passwd []byte) error                     // save the copy of decrypted wallet, the wallet is unchanged.
+	Load(r io.Reader) error                                        // load wallet from reader, secrets stay encrypted.
+	Copy() Walleter                                                // copy of self, for thread safe.
+	GetCrypto() *encrypt.Params                                    // get the cipher and kdf parameters of secrets.
+	SetCipher(opts encrypt.Options)                                // set the cipher and kdf options for next encryption.
 }
 
 // wltDir default wallet dir, wallet file name sturct: $type_$lable.wlt.
 // example: spo_lable.wlt, skycoin_lable.wlt.
 var wltDir = filepath.Join(file.UserHome(), ".wallet-family")
 
-// Version represents the current wallet version
-var WalletVersion = "0.1"
+// WalletVersion represents the current wallet version,
+// wallet files of older version are upgraded by the registered migrations.
+var WalletVersion = "0.5"
 var WalletType = "deterministic"
 
+// CollectionWalletType wallet holds imported keys which are not derived from seed.
+var CollectionWalletType = "collection"
+
 // Ext wallet file extension name
 var Ext = "wlt"
 
+// encryptOptions the cipher and kdf options used by wallets without recorded parameters.
+var encryptOptions = encrypt.DefaultOptions
+
+// rewrapOptions wallets are re-encrypted with these options on next unlock if set.
+var rewrapOptions *encrypt.Options
+var encryptOptionsMtx sync.Mutex
+
+var logger = logging.MustGetLogger("wallet")
+
 // Creator wallet creator.
 type Creator func() Walleter
 
@@ -61,6 +105,18 @@ func RegisterCreator(tp string, ctor Creator) error {
 	return nil
 }
 
+// CoinTypes returns the registered coin types except watch-only wallet, sorted.
+func CoinTypes() []string {
+	tps := make([]string, 0, len(gWalletCreators))
+	for tp := range gWalletCreators {
+		if tp != WatchType {
+			tps = append(tps, tp)
+		}
+	}
+	sort.Strings(tps)
+	return tps
+}
+
 // InitDir initialize the wallet file storage dir,
 // load wallets if exist.
 func InitDir(path string) {
@@ -77,12 +133,85 @@ func InitDir(path string) {
 		}
 	}
 
+	gWallets.setStorage(NewFileStorage(path))
+}
+
+// InitStorage use the storage to persist wallets instead of the wallet dir,
+// such as memory storage for testing or database on server.
+func InitStorage(s Storage) {
+	gWallets.setStorage(s)
+}
+
+// LoadReport reports the result of loading each wallet file.
+type LoadReport struct {
+	Loaded    []string      `json:"loaded"`    // ids of loaded wallets
+	Skipped   []LoadFailure `json:"skipped"`   // files of unsupported coin type or version
+	Corrupt   []LoadFailure `json:"corrupt"`   // files can't be read or decoded
+	Recovered []Recovery    `json:"recovered"` // files recovered from temp or backup copies
+}
+
+// LoadFailure records the wallet file which is not loaded.
+type LoadFailure struct {
+	Name   string `json:"name"`   // wallet file name
+	Reason string `json:"reason"` // why the file is not loaded
 }
 
-// LoadWallet load wallet from disk
-func LoadWallet(passwd string) error {
+// LoadWallet load wallet from disk, the wallets are not decrypted,
+// each wallet is unlocked by its own password when needed.
+// Bad files don't stop loading, they are listed in the report.
+func LoadWallet() (LoadReport, error) {
 	// load wallets.
-	return gWallets.mustLoad(passwd)
+	return gWallets.load()
+}
+
+// SetEncryptOptions set the cipher and kdf options used by new wallets, existing
+// wallets keep the cipher and kdf parameters recorded in their files.
+func SetEncryptOptions(opts encrypt.Options) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	encryptOptionsMtx.Lock()
+	encryptOptions = opts
+	encryptOptionsMtx.Unlock()
+	return nil
+}
+
+func getEncryptOptions() encrypt.Options {
+	encryptOptionsMtx.Lock()
+	defer encryptOptionsMtx.Unlock()
+	return encryptOptions
+}
+
+// SetRewrapOptions wallets encrypted with other cipher or kdf parameters are re-encrypted
+// with opts on their next successful unlock, nil opts disables the re-wrap.
+func SetRewrapOptions(opts *encrypt.Options) error {
+	if opts != nil {
+		if err := opts.Validate(); err != nil {
+			return err
+		}
+		o := *opts
+		opts = &o
+	}
+	encryptOptionsMtx.Lock()
+	rewrapOptions = opts
+	encryptOptionsMtx.Unlock()
+	return nil
+}
+
+func getRewrapOptions() *encrypt.Options {
+	encryptOptionsMtx.Lock()
+	defer encryptOptionsMtx.Unlock()
+	return rewrapOptions
+}
+
+// SetCipher re-encrypts the wallet of specific id with the cipher and kdf options.
+func SetCipher(id, passwd string, opts encrypt.Options) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	p := secure.FromString(passwd)
+	defer secure.Wipe(p)
+	return gWallets.setCipher(id, p, opts)
 }
 
 // Reset clear wallets in memory
@@ -95,11 +224,42 @@ func GetWalletDir() string {
 	return wltDir
 }
 
-// New create wallet base on seed and coin type.
+// New create wallet base on seed and coin type, the seed must be bip39 mnemonic of
+// the supported wordlists, random english mnemonic is generated if seed is empty.
 func New(tp, lable, seed, passwd string) (Walleter, error) {
-	if gWallets.verifyPassword(passwd) != nil {
-		return nil, fmt.Errorf("wallet password incorrect")
+	return NewWithPassphrase(tp, lable, seed, "", passwd)
+}
+
+// NewWithPassphrase create wallet base on bip39 mnemonic and passphrase, the addresses are
+// derived from the bip39 seed of both, so each passphrase opens another wallet of the mnemonic.
+// The passphrase is kept encrypted with the seed, empty passphrase is the same as New.
+func NewWithPassphrase(tp, lable, seed, passphrase, passwd string) (Walleter, error) {
+	if seed != "" {
+		var err error
+		if seed, err = NormalizeMnemonic(seed); err != nil {
+			return nil, err
+		}
+	}
+	return newWallet(tp, lable, seed, passphrase, passwd, "", 1)
+}
+
+// NormalizeMnemonic validates the mnemonic and returns it NFKD normalized, the words separated
+// by other spaces or typed in other unicode forms are the same mnemonic, and derive the same keys.
+func NormalizeMnemonic(seed string) (string, error) {
+	if err := ValidateMnemonic(seed); err != nil {
+		return "", err
 	}
+	return strings.Join(strings.Fields(norm.NFKD.String(seed)), " "), nil
+}
+
+// NewBrainWallet create wallet base on arbitrary seed without validation, a mistyped seed
+// silently creates another wallet, it's only for the users who opt in brain wallet.
+func NewBrainWallet(tp, lable, seed, passwd string) (Walleter, error) {
+	return newWallet(tp, lable, seed, "", passwd, "", 1)
+}
+
+// newWallet creates the wallet with num addresses, group is the id of linked wallets.
+func newWallet(tp, lable, seed, passphrase, passwd, group string, num int) (Walleter, error) {
 	newWlt, ok := gWalletCreators[tp]
 	if !ok {
 		return nil, fmt.Errorf("%s wallet not regestered", tp)
@@ -118,35 +278,153 @@ func New(tp, lable, seed, passwd string) (Walleter, error) {
 		seed = NewSeed()
 	}
 
-	wlt.SetID(MakeWltID(tp, seed))
+	if passphrase == "" {
+		wlt.SetID(MakeWltID(tp, seed))
+	} else {
+		// the id is made of the first address, which is derived from the bip39 seed.
+		m, p := secure.FromString(seed), secure.FromString(passphrase)
+		root := mnemonicSeed(m, p)
+		wlt.SetID(makeWltID(tp, root))
+		secure.Wipe(root)
+		secure.Wipe(m)
+		secure.Wipe(p)
+	}
 
 	wlt.SetSeed(seed)
 
+	wlt.SetPassphrase(passphrase)
+
+	wlt.SetGroup(group)
+
 	// Validate the wallet
 	if err := wlt.Validate(); err != nil {
 		return nil, err
 	}
 
-	if err := gWallets.add(wlt, passwd); err != nil {
+	p := secure.FromString(passwd)
+	defer secure.Wipe(p)
+	if err := gWallets.add(wlt, p); err != nil {
 		return nil, err
 	}
 
 	// generate 1 address default
-	if _, err := gWallets.newAddresses(wlt.GetID(), 1, passwd); err != nil {
+	if _, err := gWallets.newAddresses(wlt.GetID(), num, p); err != nil {
 		return nil, err
 	}
 
 	return wlt.Copy(), nil
 }
 
-// NewSeed generates mnemonic seed
-func NewSeed() string {
-	entropy, err := bip39.NewEntropy(128)
+// NewMultiCoinWallet create linked wallets of the coin types from one bip39 mnemonic, empty
+// coinTypes means every registered coin. The wallets share the group id made of the seed,
+// restoring with the same seed gets the same wallets and group, removing any wallet of the
+// group removes all of them. The lable of each wallet is its coin type.
+func NewMultiCoinWallet(seed, passwd string, coinTypes []string) (string, []Walleter, error) {
+	if len(coinTypes) == 0 {
+		coinTypes = CoinTypes()
+	}
+
+	if seed == "" {
+		seed = NewSeed()
+	}
+	seed, err := NormalizeMnemonic(seed)
 	if err != nil {
-		panic(err)
+		return "", nil, err
 	}
 
-	sd, err := bip39.NewMnemonic(entropy)
+	seen := make(map[string]bool)
+	for _, tp := range coinTypes {
+		if _, ok := gWalletCreators[tp]; !ok || tp == WatchType {
+			return "", nil, fmt.Errorf("%s wallet not regestered", tp)
+		}
+		if seen[tp] {
+			return "", nil, fmt.Errorf("duplicate coin type %s", tp)
+		}
+		seen[tp] = true
+		if id := MakeWltID(tp, seed); IsExist(id) {
+			return "", nil, fmt.Errorf("%s already exist", id)
+		}
+	}
+
+	group := MakeWltID("group", seed)
+	wlts := make([]Walleter, 0, len(coinTypes))
+	for _, tp := range coinTypes {
+		wlt, err := newWallet(tp, tp, seed, "", passwd, group, 1)
+		if err != nil {
+			// the wallets are created entirely or not at all.
+			ids := make([]string, len(wlts))
+			for i, w := range wlts {
+				ids[i] = w.GetID()
+			}
+			gWallets.removeAll(ids)
+			return "", nil, err
+		}
+		wlts = append(wlts, wlt)
+	}
+	return group, wlts, nil
+}
+
+// GetGroup returns ids of the wallets in group, sorted by id.
+func GetGroup(group string) []string {
+	return gWallets.getGroup(group)
+}
+
+// NewCollection create collection wallet of coin type, the wallet has no seed,
+// keys are imported by ImportKey.
+func NewCollection(tp, lable, passwd string) (Walleter, error) {
+	newWlt, ok := gWalletCreators[tp]
+	if !ok {
+		return nil, fmt.Errorf("%s wallet not regestered", tp)
+	}
+
+	wlt := newWlt()
+
+	wlt.SetConstant()
+
+	wlt.SetWalletType(CollectionWalletType)
+
+	wlt.SetTime(fmt.Sprintf("%v", time.Now().Unix()))
+
+	wlt.SetLable(lable)
+
+	// no seed to derive the id from.
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	wlt.SetID(fmt.Sprintf("%s_%x", tp, b))
+
+	// Validate the wallet
+	if err := wlt.Validate(); err != nil {
+		return nil, err
+	}
+
+	p := secure.FromString(passwd)
+	defer secure.Wipe(p)
+	if err := gWallets.add(wlt, p); err != nil {
+		return nil, err
+	}
+
+	return wlt.Copy(), nil
+}
+
+// ImportKey import the secret key in hex into collection wallet, returns the address of the key.
+func ImportKey(id, seckey, passwd string) (string, error) {
+	k := secure.FromString(seckey)
+	defer secure.Wipe(k)
+	sec, err := unhexBytes(k)
+	if err != nil {
+		return "", errors.New("invalid secret key")
+	}
+	defer secure.Wipe(sec)
+	p := secure.FromString(passwd)
+	defer secure.Wipe(p)
+	return gWallets.importKey(id, sec, p)
+}
+
+// NewSeed generates 128 bits english mnemonic seed
+func NewSeed() string {
+	sd, err := NewSeedWithOptions(128, LangEnglish)
 	if err != nil {
 		panic(err)
 	}
@@ -160,7 +438,11 @@ func IsExist(id string) bool {
 
 // MakeWltID make wallet id base on coin type and first address[0:16]
 func MakeWltID(cp, seed string) string {
-	_, seckeys := cipher.GenerateDeterministicKeyPairsSeed([]byte(seed), 1)
+	return makeWltID(cp, []byte(seed))
+}
+
+func makeWltID(cp string, seed []byte) string {
+	_, seckeys := cipher.GenerateDeterministicKeyPairsSeed(seed, 1)
 	pub := cipher.PubKeyFromSecKey(seckeys[0])
 	address := cipher.AddressFromPubKey(pub).String()[0:16]
 	return fmt.Sprintf("%s_%s", cp, address)
@@ -168,10 +450,33 @@ func MakeWltID(cp, seed string) string {
 
 // NewAddresses create address
 func NewAddresses(id string, num int, passwd string) ([]coin.AddressEntry, error) {
-	if gWallets.verifyPassword(passwd) != nil {
-		return nil, fmt.Errorf("wallet password incorrect")
-	}
-	return gWallets.newAddresses(id, num, passwd)
+	p := secure.FromString(passwd)
+	defer secure.Wipe(p)
+	return gWallets.newAddresses(id, num, p)
+}
+
+// VerifyPassword check the password of specific wallet.
+func VerifyPassword(id, passwd string) error {
+	p := secure.FromString(passwd)
+	defer secure.Wipe(p)
+	return gWallets.verifyPassword(id, p)
+}
+
+// ChangePassword re-encrypts the wallet of specific id with the new password.
+func ChangePassword(id, oldPasswd, newPasswd string) error {
+	o, n := secure.FromString(oldPasswd), secure.FromString(newPasswd)
+	defer secure.Wipe(o)
+	defer secure.Wipe(n)
+	return gWallets.changePassword([]string{id}, o, n)
+}
+
+// ChangeAllPasswords re-encrypts all wallets with the new password, watch-only wallets
+// have no password and are skipped. Every wallet file is rolled back if any of them fails.
+func ChangeAllPasswords(oldPasswd, newPasswd string) error {
+	o, n := secure.FromString(oldPasswd), secure.FromString(newPasswd)
+	defer secure.Wipe(o)
+	defer secure.Wipe(n)
+	return gWallets.changePassword(gWallets.ids(), o, n)
 }
 
 // GetAddresses get all addresses in specific wallet.
@@ -179,9 +484,83 @@ func GetAddresses(id string) ([]string, error) {
 	return gWallets.getAddresses(id)
 }
 
-// GetSeed get seed in specific wallet.
+// GetSeed get seed in specific wallet, the string returned can't be wiped, see GetSeedBytes.
 func GetSeed(id, passwd string) (string, error) {
-	return gWallets.getSeed(id, passwd)
+	seed, err := GetSeedBytes(id, passwd)
+	if err != nil {
+		return "", err
+	}
+	defer secure.Wipe(seed)
+	return string(seed), nil
+}
+
+// GetSeedBytes get seed in specific wallet, the seed is in buffer allocated by
+// secure.Bytes, the caller wipes it after use.
+func GetSeedBytes(id, passwd string) ([]byte, error) {
+	p := secure.FromString(passwd)
+	defer secure.Wipe(p)
+	return gWallets.getSeed(id, p)
+}
+
+// WalletInfo is the public info of wallet.
+type WalletInfo struct {
+	ID           string `json:"id"`
+	Type         string `json:"type"`
+	Lable        string `json:"lable"`
+	Group        string `json:"group,omitempty"`
+	Tm           string `json:"tm"`
+	AddressCount int    `json:"address_count"`
+	Version      string `json:"version"`
+	WalletType   string `json:"wallet_type"`
+	Verified     bool   `json:"verified"` // public fields are verified by the mac since loaded, which needs the password.
+}
+
+// ListWallets returns the info of loaded wallets, sorted by id.
+func ListWallets() []WalletInfo {
+	return gWallets.list()
+}
+
+// SetLable changes the lable of wallet without password, the lable isn't covered by the mac.
+func SetLable(id, lable string) error {
+	return gWallets.setLable(id, lable)
+}
+
+// GetAddressEntries returns the address entries with their label, derivation index,
+// created time and used flag.
+func GetAddressEntries(id string) ([]coin.AddressEntry, error) {
+	return gWallets.getAddressEntries(id)
+}
+
+// GetVerifiedAddressEntries returns the address entries and whether the wallet was verified
+// by the mac since loaded, the entries of unverified wallet may have been modified on disk.
+func GetVerifiedAddressEntries(id string) ([]coin.AddressEntry, bool, error) {
+	return gWallets.getVerifiedEntries(id)
+}
+
+// IsVerified checks the public fields of the wallet were verified by the mac since loaded.
+func IsVerified(id string) (bool, error) {
+	_, verified, err := gWallets.getVerifiedEntries(id)
+	return verified, err
+}
+
+// SetAddressLabel set the label of address in wallet, the password is not required
+// as the metadata of addresses isn't encrypted.
+func SetAddressLabel(id, addr, label string) error {
+	return gWallets.updatePublic(id, func(wlt Walleter) error {
+		return wlt.SetAddressLabel(addr, label)
+	})
+}
+
+// SetAddressesUsed set the used flag of addresses in wallet, key of used is the address.
+func SetAddressesUsed(id string, used map[string]bool) error {
+	return gWallets.updatePublic(id, func(wlt Walleter) error {
+		for addr, u := range used {
+			if err := wlt.SetAddressUsed(addr, u); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 // IsContain check if the addresses are int the wallet.
@@ -191,10 +570,47 @@ func IsContain(id string, addrs []string) (bool, error) {
 
 // GetKeypair get pub/sec key pair of specific addresse in wallet.
 func GetKeypair(id, addr, passwd string) (string, string, error) {
-	return gWallets.getKeypair(id, addr, passwd)
+	p := secure.FromString(passwd)
+	defer secure.Wipe(p)
+	return gWallets.getKeypair(id, addr, p)
+}
+
+// GetSeckey get the secret key of specific address in wallet,
+// the key is in buffer allocated by secure.Bytes, the caller wipes it after use.
+func GetSeckey(id, addr, passwd string) ([]byte, error) {
+	p := secure.FromString(passwd)
+	defer secure.Wipe(p)
+	return gWallets.getSeckey(id, addr, p)
+}
+
+// GetSeckeys get the secret keys of addresses in wallet by one decryption, such as the keys
+// of all inputs of a transaction, the keys are in buffers allocated by secure.Bytes, the
+// caller wipes them after use.
+func GetSeckeys(id string, addrs []string, passwd string) ([][]byte, error) {
+	p := secure.FromString(passwd)
+	defer secure.Wipe(p)
+	return gWallets.getSeckeys(id, addrs, p)
+}
+
+// Unlock keeps the secrets of the wallet decrypted for ttl, operations on the unlocked
+// wallet accept empty password and don't decrypt again until it's locked or expired.
+func Unlock(id, passwd string, ttl time.Duration) error {
+	p := secure.FromString(passwd)
+	defer secure.Wipe(p)
+	return gWallets.unlock(id, p, ttl)
+}
+
+// Lock erases the secrets of the unlocked wallet.
+func Lock(id string) error {
+	return gWallets.lock(id)
+}
+
+// IsUnlocked checks the wallet is unlocked.
+func IsUnlocked(id string) bool {
+	return gWallets.isUnlocked(id)
 }
 
-// Remove remove wallet of specific id.
+// Remove remove wallet of specific id, the linked wallets of its group are removed too.
 func Remove(id string) error {
 	return gWallets.remove(id)
 }
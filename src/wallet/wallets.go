@@ -1,71 +1,158 @@
 package wallet
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
-	"os"
-	"path/filepath"
+	"io"
+	"sort"
 	"strings"
 	"sync"
 
 	"github.com/MDLlife/wallet-api/src/coin"
+	"github.com/MDLlife/wallet-api/src/util/encrypt"
 )
 
 // wallets record all wallet, key is wallet id, value is wallet interface.
 type wallets struct {
-	mtx   sync.Mutex
-	Value map[string]Walleter
+	mtx      sync.Mutex
+	Value    map[string]Walleter
+	storage  Storage
+	sessions map[string]*session // sessions of unlocked wallets
 }
 
 // internal global wallets
-var gWallets = wallets{Value: make(map[string]Walleter)}
+var gWallets = wallets{
+	Value:    make(map[string]Walleter),
+	storage:  NewFileStorage(wltDir),
+	sessions: make(map[string]*session),
+}
+
+var errPasswordIncorrect = errors.New("wallet password incorrect")
 
-func (wlts *wallets) add(wlt Walleter, passwd string) error {
+func (wlts *wallets) add(wlt Walleter, passwd []byte) error {
 	wlts.mtx.Lock()
 	defer wlts.mtx.Unlock()
 	if _, ok := wlts.Value[wlt.GetID()]; ok {
 		return fmt.Errorf("%s already exist", wlt.GetID())
 	}
+	if err := wlts.store(wlt, passwd); err != nil {
+		return err
+	}
 	wlts.Value[wlt.GetID()] = wlt
-	return wlts.store(wlt, passwd)
+	return nil
 }
 
+// remove removes the wallet, the linked wallets of its group are removed too.
 func (wlts *wallets) remove(id string) error {
 	wlts.mtx.Lock()
 	defer wlts.mtx.Unlock()
 
-	if wlt, ok := wlts.Value[id]; ok {
-		path := storeAddr(wlt)
-		if _, err := os.Stat(path); !os.IsNotExist(err) {
-			if err := os.RemoveAll(path); err != nil {
-				return err
-			}
-			delete(wlts.Value, id)
+	wlt, ok := wlts.Value[id]
+	if !ok {
+		return nil
+	}
+	ids := []string{id}
+	if group := wlt.GetGroup(); group != "" {
+		ids = wlts.groupIDs(group)
+	}
+	return wlts.removeIDs(ids)
+}
+
+// removeAll removes the wallets of ids only, the groups are not cascaded.
+func (wlts *wallets) removeAll(ids []string) error {
+	wlts.mtx.Lock()
+	defer wlts.mtx.Unlock()
+	return wlts.removeIDs(ids)
+}
+
+func (wlts *wallets) removeIDs(ids []string) error {
+	for _, id := range ids {
+		wlt, ok := wlts.Value[id]
+		if !ok {
+			continue
+		}
+		if err := wlts.storage.Remove(storeName(wlt)); err != nil {
+			return err
 		}
+		wlts.lockWallet(id)
+		delete(wlts.Value, id)
 	}
 	return nil
 }
 
+// groupIDs returns ids of the wallets in group, sorted by id.
+func (wlts *wallets) groupIDs(group string) []string {
+	ids := []string{}
+	for id, wlt := range wlts.Value {
+		if wlt.GetGroup() == group {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (wlts *wallets) getGroup(group string) []string {
+	wlts.mtx.Lock()
+	defer wlts.mtx.Unlock()
+	return wlts.groupIDs(group)
+}
+
+func (wlts *wallets) setStorage(s Storage) {
+	wlts.mtx.Lock()
+	defer wlts.mtx.Unlock()
+	if c, ok := wlts.storage.(io.Closer); ok && wlts.storage != s {
+		c.Close()
+	}
+	wlts.storage = s
+}
+
 func (wlts *wallets) reset() {
 	wlts.mtx.Lock()
+	for id := range wlts.sessions {
+		wlts.lockWallet(id)
+	}
 	wlts.Value = make(map[string]Walleter)
 	wlts.mtx.Unlock()
 }
 
-// load from local disk
-func (wlts *wallets) mustLoad(passwd string) error {
+// load loads the wallet files from storage, secrets of each wallet stay encrypted
+// with its own password. Broken files are reported and skipped, the others are loaded.
+func (wlts *wallets) load() (LoadReport, error) {
 	// clear wallets in memory.
 	wlts.reset()
 
-	fileInfos, _ := ioutil.ReadDir(wltDir)
-	for _, fileInfo := range fileInfos {
-		name := fileInfo.Name()
-		if !strings.HasSuffix(name, ".wlt") {
+	report := LoadReport{
+		Loaded:    []string{},
+		Skipped:   []LoadFailure{},
+		Corrupt:   []LoadFailure{},
+		Recovered: []Recovery{},
+	}
+
+	// replace the files broken by interrupted writes with their newest valid copies.
+	if r, ok := wlts.storage.(Recoverer); ok {
+		recoveries, err := r.Recover(validateFile)
+		if err != nil {
+			logger.Warningf("recover wallet files failed: %v", err)
+		}
+		report.Recovered = append(report.Recovered, recoveries...)
+	}
+
+	names, err := wlts.storage.List()
+	if err != nil {
+		return report, err
+	}
+	for _, name := range names {
+		if !strings.HasSuffix(name, "."+Ext) {
 			continue
 		}
 		// get the wallet type, the name: $bitcoin_$seed1234.wlt
 		typeLable := strings.SplitN(name, "_", 2)
 		if len(typeLable) != 2 {
+			report.Skipped = append(report.Skipped, LoadFailure{name, "invalid wallet file name"})
 			continue
 		}
 
@@ -73,51 +160,132 @@ func (wlts *wallets) mustLoad(passwd string) error {
 		tp := typeLable[0]
 		newWlt, ok := gWalletCreators[tp]
 		if !ok {
-			panic(fmt.Sprintf("%s wallet not supported", tp))
+			report.Skipped = append(report.Skipped, LoadFailure{name, fmt.Sprintf("%s wallet not supported", tp)})
+			continue
 		}
 
-		f, err := os.Open(filepath.Join(wltDir, name))
+		d, err := wlts.storage.Read(name)
 		if err != nil {
-			panic(err)
+			report.Corrupt = append(report.Corrupt, LoadFailure{name, err.Error()})
+			continue
+		}
+
+		if !json.Valid(d) {
+			report.Corrupt = append(report.Corrupt, LoadFailure{name, "invalid json"})
+			continue
+		}
+
+		// upgrade the wallet file to current version.
+		d, err = migrateFile(wlts.storage, name, d)
+		if err != nil {
+			report.Skipped = append(report.Skipped, LoadFailure{name, err.Error()})
+			continue
 		}
-		defer f.Close()
 
 		wlt := newWlt()
-		if err := wlt.Load(f, passwd); err != nil {
+		if err := wlt.Load(bytes.NewReader(d)); err != nil {
+			report.Corrupt = append(report.Corrupt, LoadFailure{name, err.Error()})
+			continue
+		}
+
+		wlts.mtx.Lock()
+		if _, ok := wlts.Value[wlt.GetID()]; ok {
+			wlts.mtx.Unlock()
+			report.Skipped = append(report.Skipped, LoadFailure{name, fmt.Sprintf("%s already exist", wlt.GetID())})
+			continue
+		}
+		wlts.Value[wlt.GetID()] = wlt
+		wlts.mtx.Unlock()
+		report.Loaded = append(report.Loaded, wlt.GetID())
+	}
+
+	return report, nil
+}
+
+// validateFile checks the wallet file can be loaded as the loader does, the secrets can't
+// be decrypted without password, so the structure, mac and crypto parameters are checked.
+func validateFile(d []byte) error {
+	var wlt Wallet
+	if err := json.Unmarshal(d, &wlt); err != nil {
+		return err
+	}
+	// the file of unknown version is written by newer app, the loader skips it.
+	if _, ok := gMigrations[wlt.Version]; !ok && wlt.Version != WalletVersion {
+		return nil
+	}
+	d, _, err := migrate(d)
+	if err != nil {
+		return err
+	}
+	wlt = Wallet{}
+	if err := json.Unmarshal(d, &wlt); err != nil {
+		return err
+	}
+	// watch-only wallet has no secrets.
+	if wlt.ID == "" || wlt.Type == "" || (wlt.Secrets == "" && wlt.WalletType != WatchWalletType) {
+		return errors.New("incomplete wallet file")
+	}
+	newWlt, ok := gWalletCreators[strings.SplitN(wlt.ID, "_", 2)[0]]
+	if !ok {
+		return fmt.Errorf("%s wallet not supported", wlt.ID)
+	}
+	if err := newWlt().Load(bytes.NewReader(d)); err != nil {
+		return err
+	}
+	if wlt.Crypto != nil {
+		if err := wlt.Crypto.Validate(); err != nil {
 			return err
 		}
-		if err := wlts.add(wlt, passwd); err != nil {
+		if _, err := base64.StdEncoding.DecodeString(wlt.Secrets); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-// verifyPassword verify that password is correct or not by decrypt wallet
-// passwd if right if there is no wallet
-func (wlts *wallets) verifyPassword(passwd string) error {
+// checkPassword returns errPasswordIncorrect if passwd is wrong, the tamper error
+// if the public fields of the wallet were modified, or errWatchOnly.
+func checkPassword(wlt Walleter, passwd []byte) error {
+	if err := wlt.IsPasswordCorrect(passwd); err != nil {
+		if err == errWalletTampered || err == errWatchOnly {
+			return err
+		}
+		return errPasswordIncorrect
+	}
+	return nil
+}
+
+// verifyPassword verify that password is correct or not by decrypt the specific wallet,
+// every wallet is encrypted with its own password.
+func (wlts *wallets) verifyPassword(id string, passwd []byte) error {
 	wlts.mtx.Lock()
 	defer wlts.mtx.Unlock()
-	for _, wlt := range wlts.Value {
-		return wlt.IsPasswordCorrect(passwd)
+	if wlt, ok := wlts.Value[id]; ok {
+		_, err := wlts.password(wlt, passwd)
+		return err
 	}
-	return nil
+	return fmt.Errorf("%s wallet does not exist", id)
 }
 
-func (wlts *wallets) newAddresses(id string, num int, passwd string) ([]coin.AddressEntry, error) {
+func (wlts *wallets) newAddresses(id string, num int, passwd []byte) ([]coin.AddressEntry, error) {
 
 	wlts.mtx.Lock()
 	defer wlts.mtx.Unlock()
 	if wlt, ok := wlts.Value[id]; ok {
-		// Todo
+		passwd, err := wlts.sessionPassword(wlt, passwd)
+		if err != nil {
+			return []coin.AddressEntry{}, err
+		}
 		if err := wlt.Decryption(passwd); err != nil {
 			return []coin.AddressEntry{}, err
 		}
 
 		addrs, err := wlt.NewAddresses(num)
 		if err != nil {
+			wlt.Erase()
 			return []coin.AddressEntry{}, err
 		}
+		setRewrapCipher(wlt, passwd)
 
 		// Encrypted and erased in store
 		if err := wlts.store(wlt, passwd); err != nil {
@@ -128,6 +296,34 @@ func (wlts *wallets) newAddresses(id string, num int, passwd string) ([]coin.Add
 	return []coin.AddressEntry{}, fmt.Errorf("%s wallet does not exist", id)
 }
 
+func (wlts *wallets) importKey(id string, seckey, passwd []byte) (string, error) {
+	wlts.mtx.Lock()
+	defer wlts.mtx.Unlock()
+	if wlt, ok := wlts.Value[id]; ok {
+		passwd, err := wlts.sessionPassword(wlt, passwd)
+		if err != nil {
+			return "", err
+		}
+		if err := wlt.Decryption(passwd); err != nil {
+			return "", err
+		}
+
+		entry, err := wlt.ImportKey(seckey)
+		if err != nil {
+			wlt.Erase()
+			return "", err
+		}
+		setRewrapCipher(wlt, passwd)
+
+		// Encrypted and erased in store
+		if err := wlts.store(wlt, passwd); err != nil {
+			return "", err
+		}
+		return entry.Address, nil
+	}
+	return "", fmt.Errorf("%s wallet does not exist", id)
+}
+
 func (wlts *wallets) getAddresses(id string) ([]string, error) {
 	wlts.mtx.Lock()
 	defer wlts.mtx.Unlock()
@@ -137,13 +333,102 @@ func (wlts *wallets) getAddresses(id string) ([]string, error) {
 	return []string{}, fmt.Errorf("%s wallet does not exist", id)
 }
 
-func (wlts *wallets) getSeed(id, passwd string) (string, error) {
+func (wlts *wallets) getAddressEntries(id string) ([]coin.AddressEntry, error) {
 	wlts.mtx.Lock()
 	defer wlts.mtx.Unlock()
 	if wlt, ok := wlts.Value[id]; ok {
-		return wlt.GetSeed(passwd), nil
+		return wlt.GetAddressEntries(), nil
 	}
-	return "", fmt.Errorf("%s wallet does not exist", id)
+	return []coin.AddressEntry{}, fmt.Errorf("%s wallet does not exist", id)
+}
+
+// getVerifiedEntries returns the address entries with the verified flag of the same wallet.
+func (wlts *wallets) getVerifiedEntries(id string) ([]coin.AddressEntry, bool, error) {
+	wlts.mtx.Lock()
+	defer wlts.mtx.Unlock()
+	if wlt, ok := wlts.Value[id]; ok {
+		return wlt.GetAddressEntries(), wlt.IsVerified(), nil
+	}
+	return []coin.AddressEntry{}, false, fmt.Errorf("%s wallet does not exist", id)
+}
+
+// updatePublic updates the public fields which aren't covered by the mac and saves the
+// wallet without password, the encrypted secrets and mac are kept.
+func (wlts *wallets) updatePublic(id string, update func(wlt Walleter) error) error {
+	wlts.mtx.Lock()
+	defer wlts.mtx.Unlock()
+	wlt, ok := wlts.Value[id]
+	if !ok {
+		return fmt.Errorf("%s wallet does not exist", id)
+	}
+	return wlts.storePublic(wlt, update)
+}
+
+func (wlts *wallets) storePublic(wlt Walleter, update func(wlt Walleter) error) error {
+	// the copy without decrypted secrets is saved, the wallet in memory
+	// is updated after saved, which keeps its unlock session.
+	w := wlt.Copy()
+	if err := update(w); err != nil {
+		return err
+	}
+	d, err := json.MarshalIndent(w, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := wlts.storage.Write(storeName(w), d); err != nil {
+		return err
+	}
+	return update(wlt)
+}
+
+// setLable changes the lable without password, the lable isn't covered by the mac.
+func (wlts *wallets) setLable(id, lable string) error {
+	return wlts.updatePublic(id, func(wlt Walleter) error {
+		wlt.SetLable(lable)
+		return nil
+	})
+}
+
+// list returns the public info of wallets, sorted by id.
+func (wlts *wallets) list() []WalletInfo {
+	wlts.mtx.Lock()
+	defer wlts.mtx.Unlock()
+	infos := make([]WalletInfo, 0, len(wlts.Value))
+	for _, wlt := range wlts.Value {
+		infos = append(infos, WalletInfo{
+			ID:           wlt.GetID(),
+			Type:         wlt.GetType(),
+			Lable:        wlt.GetLable(),
+			Group:        wlt.GetGroup(),
+			Tm:           wlt.GetTime(),
+			AddressCount: len(wlt.GetAddresses()),
+			Version:      wlt.GetVersion(),
+			WalletType:   wlt.GetWalletType(),
+			Verified:     wlt.IsVerified(),
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ID < infos[j].ID
+	})
+	return infos
+}
+
+func (wlts *wallets) getSeed(id string, passwd []byte) ([]byte, error) {
+	wlts.mtx.Lock()
+	defer wlts.mtx.Unlock()
+	if wlt, ok := wlts.Value[id]; ok {
+		passwd, err := wlts.sessionPassword(wlt, passwd)
+		if err != nil {
+			return nil, err
+		}
+		seed, err := wlt.GetSeedBytes(passwd)
+		if err != nil {
+			return nil, err
+		}
+		wlts.rewrap(wlt, passwd)
+		return seed, nil
+	}
+	return nil, fmt.Errorf("%s wallet does not exist", id)
 }
 
 func (wlts *wallets) isContain(id string, addrs []string) (bool, error) {
@@ -169,41 +454,238 @@ func (wlts *wallets) isContain(id string, addrs []string) (bool, error) {
 	return false, fmt.Errorf("wallet %s does not exist", id)
 }
 
-func (wlts *wallets) getKeypair(id, addr, passwd string) (string, string, error) {
+func (wlts *wallets) getKeypair(id, addr string, passwd []byte) (string, string, error) {
 	wlts.mtx.Lock()
 	defer wlts.mtx.Unlock()
 	if wlt, ok := wlts.Value[id]; ok {
-		return wlt.GetKeypair(addr, passwd)
+		// the locked wallet verifies the password by decryption in GetKeypair.
+		passwd, err := wlts.sessionPassword(wlt, passwd)
+		if err != nil {
+			return "", "", err
+		}
+		p, s, err := wlt.GetKeypair(addr, passwd)
+		if err != nil {
+			return "", "", err
+		}
+		wlts.rewrap(wlt, passwd)
+		return p, s, nil
 	}
 	return "", "", fmt.Errorf("%s wallet does not exist", id)
 }
 
-func (wlts *wallets) store(wlt Walleter, passwd string) error {
-	if wlt.GetID() == "" {
-		return fmt.Errorf("wrong wallet info %v", wlt)
+func (wlts *wallets) getSeckey(id, addr string, passwd []byte) ([]byte, error) {
+	wlts.mtx.Lock()
+	defer wlts.mtx.Unlock()
+	if wlt, ok := wlts.Value[id]; ok {
+		// the locked wallet verifies the password by decryption in GetSeckey.
+		passwd, err := wlts.sessionPassword(wlt, passwd)
+		if err != nil {
+			return nil, err
+		}
+		s, err := wlt.GetSeckey(addr, passwd)
+		if err != nil {
+			return nil, err
+		}
+		wlts.rewrap(wlt, passwd)
+		return s, nil
 	}
-	path := storeAddr(wlt)
-	tmpPath := path + "." + "tmp"
+	return nil, fmt.Errorf("%s wallet does not exist", id)
+}
 
-	// write wallet to temp file.
-	f, err := os.Create(tmpPath)
-	if err != nil {
-		return err
+func (wlts *wallets) getSeckeys(id string, addrs []string, passwd []byte) ([][]byte, error) {
+	wlts.mtx.Lock()
+	defer wlts.mtx.Unlock()
+	if wlt, ok := wlts.Value[id]; ok {
+		passwd, err := wlts.sessionPassword(wlt, passwd)
+		if err != nil {
+			return nil, err
+		}
+		s, err := wlt.GetSeckeys(addrs, passwd)
+		if err != nil {
+			return nil, err
+		}
+		wlts.rewrap(wlt, passwd)
+		return s, nil
 	}
-	defer f.Close()
+	return nil, fmt.Errorf("%s wallet does not exist", id)
+}
 
-	if err := wlt.Save(f, passwd); err != nil {
-		return err
+// changePassword re-encrypts the wallets of ids with newPasswd, all wallets are
+// rolled back to the old files if any of them fails, the old files are removed after
+// all of them are stored. The wallets are decrypted by
+// oldPasswd before any of them is stored, which verifies the password as well.
+func (wlts *wallets) changePassword(ids []string, oldPasswd, newPasswd []byte) error {
+	wlts.mtx.Lock()
+	defer wlts.mtx.Unlock()
+
+	olds := make([]Walleter, 0, len(ids))
+	eraseAll := func() {
+		for _, old := range olds {
+			wlts.Value[old.GetID()].Erase()
+		}
+	}
+	for _, id := range ids {
+		wlt, ok := wlts.Value[id]
+		if !ok {
+			eraseAll()
+			return fmt.Errorf("%s wallet does not exist", id)
+		}
+		if err := wlts.decryptOld(wlt, oldPasswd); err != nil {
+			eraseAll()
+			return fmt.Errorf("%s %v", id, err)
+		}
+		olds = append(olds, wlt.Copy())
 	}
 
-	// create bak file if exist.
-	if _, err := os.Stat(path); !os.IsNotExist(err) {
-		if err := os.Rename(path, path+".bak"); err != nil {
+	for i, old := range olds {
+		wlt := wlts.Value[old.GetID()]
+		setRewrapCipher(wlt, newPasswd)
+		// Encrypted and erased in store
+		err := wlts.store(wlt, newPasswd)
+		// the new password ends the session.
+		wlts.lockWallet(old.GetID())
+		if err != nil {
+			for _, o := range olds[i+1:] {
+				wlts.Value[o.GetID()].Erase()
+			}
+			if rbErr := wlts.rollback(olds[:i], old); rbErr != nil {
+				return fmt.Errorf("change password of %s failed: %v, rollback failed: %v", old.GetID(), err, rbErr)
+			}
+			return fmt.Errorf("change password of %s failed: %v", old.GetID(), err)
+		}
+	}
+
+	// the backups are encrypted by the old password, they are removed once all wallets
+	// are stored, the rollback needs them before.
+	for _, old := range olds {
+		if err := wlts.storage.Discard(storeName(old)); err != nil {
+			return fmt.Errorf("password changed, but the copies of %s encrypted by the old password are not removed: %v", old.GetID(), err)
+		}
+	}
+	return nil
+}
+
+// decryptOld decrypts the wallet by the old password, the unlocked wallet is already
+// decrypted, the old password is verified by decrypting its encrypted secrets.
+func (wlts *wallets) decryptOld(wlt Walleter, oldPasswd []byte) error {
+	if _, ok := wlts.sessions[wlt.GetID()]; ok {
+		return checkPassword(wlt, oldPasswd)
+	}
+	return wlt.Decryption(oldPasswd)
+}
+
+func (wlts *wallets) setCipher(id string, passwd []byte, opts encrypt.Options) error {
+	wlts.mtx.Lock()
+	defer wlts.mtx.Unlock()
+	if wlt, ok := wlts.Value[id]; ok {
+		if len(passwd) == 0 {
+			return errors.New("password is required to change the cipher")
+		}
+		passwd, err := wlts.sessionPassword(wlt, passwd)
+		if err != nil {
 			return err
 		}
+		return wlts.reencrypt(wlt, passwd, &opts)
+	}
+	return fmt.Errorf("%s wallet does not exist", id)
+}
+
+// reencrypt re-encrypts the secrets of wlt with opts, nil opts keeps the cipher, the
+// decryption verifies passwd, the wallet is rolled back if the store failed.
+func (wlts *wallets) reencrypt(wlt Walleter, passwd []byte, opts *encrypt.Options) error {
+	if err := wlt.Decryption(passwd); err != nil {
+		return err
+	}
+	old := wlt.Copy()
+	if opts != nil {
+		wlt.SetCipher(*opts)
+	}
+	// Encrypted and erased in store
+	if err := wlts.store(wlt, passwd); err != nil {
+		if rbErr := wlts.rollback(nil, old); rbErr != nil {
+			return fmt.Errorf("%v, rollback failed: %v", err, rbErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// rewrap re-encrypts the wallet with the rewrap options after it was unlocked by passwd,
+// the wallet saved before the mac is saved again with the mac as well.
+// The unlocked wallet operated without password keeps its cipher, as the key kept by it
+// can't derive another one. The wallet keeps the old secrets if failed.
+func (wlts *wallets) rewrap(wlt Walleter, passwd []byte) {
+	opts := getRewrapOptions()
+	if opts != nil && (len(passwd) == 0 || wlt.GetCrypto().Match(*opts)) {
+		opts = nil
+	}
+	if opts == nil && !wlt.IsMacPending() {
+		return
+	}
+	if err := wlts.reencrypt(wlt, passwd, opts); err != nil {
+		logger.Warningf("re-wrap wallet %s failed: %v", wlt.GetID(), err)
+	}
+}
+
+// setRewrapCipher set the rewrap options as the cipher of decrypted wallet before it's
+// stored by passwd, the wallet stored without password keeps its cipher.
+func setRewrapCipher(wlt Walleter, passwd []byte) {
+	if len(passwd) == 0 {
+		return
+	}
+	if opts := getRewrapOptions(); opts != nil && !wlt.GetCrypto().Match(*opts) {
+		wlt.SetCipher(*opts)
+	}
+}
+
+// rollback restores the stored wallets from the backups, the failed wallet is
+// restored only if its file was already moved away. The restored copies have no
+// secrets, so the sessions of the wallets are ended.
+func (wlts *wallets) rollback(stored []Walleter, failed Walleter) error {
+	var rbErr error
+	for _, wlt := range stored {
+		if err := wlts.storage.Restore(storeName(wlt)); err != nil && rbErr == nil {
+			rbErr = err
+		}
+		wlts.lockWallet(wlt.GetID())
+		wlts.Value[wlt.GetID()] = wlt
 	}
 
-	return os.Rename(tmpPath, path)
+	name := storeName(failed)
+	if !wlts.storage.Exist(name) {
+		if err := wlts.storage.Restore(name); err != nil && rbErr == nil {
+			rbErr = err
+		}
+	}
+	wlts.lockWallet(failed.GetID())
+	wlts.Value[failed.GetID()] = failed
+	return rbErr
+}
+
+func (wlts *wallets) store(wlt Walleter, passwd []byte) error {
+	if wlt.GetID() == "" {
+		return fmt.Errorf("wrong wallet info %v", wlt)
+	}
+
+	var buf bytes.Buffer
+	if err := wlt.Save(&buf, passwd); err != nil {
+		return err
+	}
+	return wlts.storage.Write(storeName(wlt), buf.Bytes())
+}
+
+// ids returns ids of the wallets which have secrets, watch-only wallets are excluded.
+func (wlts *wallets) ids() []string {
+	wlts.mtx.Lock()
+	defer wlts.mtx.Unlock()
+	ids := make([]string, 0, len(wlts.Value))
+	for id, wlt := range wlts.Value {
+		if _, ok := wlt.(*WatchWallet); ok {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
 }
 
 func (wlts *wallets) isExist(id string) bool {
@@ -215,6 +697,6 @@ func (wlts *wallets) isExist(id string) bool {
 	return false
 }
 
-func storeAddr(wlt Walleter) string {
-	return filepath.Join(wltDir, wlt.GetID()+"."+Ext)
+func storeName(wlt Walleter) string {
+	return wlt.GetID() + "." + Ext
 }
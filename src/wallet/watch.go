@@ -0,0 +1,210 @@
+package wallet
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/MDLlife/wallet-api/src/coin"
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+// WatchType is the creator type of watch-only wallets, the wallet id is $watch_$coin_$address.
+var WatchType = "watch"
+
+// WatchWalletType is the wallet_type field of watch-only wallets.
+var WatchWalletType = "watch-only"
+
+var errWatchOnly = errors.New("watch-only wallet has no secrets, signing is not supported")
+
+// WatchWallet tracks addresses whose keys live elsewhere, it has no seed and no secrets.
+// Type is the coin type of the addresses. Without secrets there is no mac key,
+// the public fields are not protected against modification.
+type WatchWallet struct {
+	Wallet
+}
+
+func init() {
+	RegisterCreator(WatchType, func() Walleter {
+		return &WatchWallet{}
+	})
+}
+
+// NewWatchWallet create watch-only wallet of coin type, keys are addresses or pubkeys in hex.
+func NewWatchWallet(coinType, lable string, keys []string) (Walleter, error) {
+	if _, ok := gWalletCreators[coinType]; !ok || coinType == WatchType {
+		return nil, fmt.Errorf("%s wallet not regestered", coinType)
+	}
+	entries, err := watchEntries(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	wlt := &WatchWallet{}
+	wlt.SetConstant()
+	wlt.Type = coinType
+	wlt.SetTime(fmt.Sprintf("%v", time.Now().Unix()))
+	wlt.SetLable(lable)
+	wlt.AddressEntries = entries
+	wlt.SetID(fmt.Sprintf("%s_%s_%s", WatchType, coinType, shortAddress(entries[0].Address)))
+
+	if err := wlt.Validate(); err != nil {
+		return nil, err
+	}
+	if err := gWallets.add(wlt, nil); err != nil {
+		return nil, err
+	}
+	return wlt.Copy(), nil
+}
+
+// watchEntries makes address entries of the addresses or pubkeys in hex.
+func watchEntries(keys []string) ([]coin.AddressEntry, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no address to watch")
+	}
+	entries := make([]coin.AddressEntry, 0, len(keys))
+	seen := make(map[string]bool)
+	now := time.Now().Unix()
+	for _, k := range keys {
+		e := coin.AddressEntry{Index: -1, Created: now}
+		if pub, err := cipher.PubKeyFromHex(k); err == nil {
+			e.Address = cipher.AddressFromPubKey(pub).String()
+			e.Public = pub.Hex()
+		} else if _, err := cipher.DecodeBase58Address(k); err == nil {
+			e.Address = k
+		} else {
+			return nil, fmt.Errorf("invalid address or pubkey %s", k)
+		}
+		if seen[e.Address] {
+			return nil, fmt.Errorf("duplicate address %s", e.Address)
+		}
+		seen[e.Address] = true
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func shortAddress(addr string) string {
+	if len(addr) > 16 {
+		return addr[:16]
+	}
+	return addr
+}
+
+// SetConstant set the version and wallet type of watch-only wallet.
+func (wlt *WatchWallet) SetConstant() {
+	wlt.Version = WalletVersion
+	wlt.WalletType = WatchWalletType
+}
+
+// SetSeed watch-only wallet has no seed.
+func (wlt *WatchWallet) SetSeed(seed string) {}
+
+// Validate validates the watch-only wallet.
+func (wlt *WatchWallet) Validate() error {
+	if wlt.ID == "" {
+		return errors.New("wallet id not set")
+	}
+
+	if wlt.Type == "" {
+		return errors.New("type field not set")
+	}
+
+	if wlt.WalletType != WatchWalletType {
+		return errors.New("wallet type invalid")
+	}
+
+	if len(wlt.AddressEntries) == 0 {
+		return errors.New("no address to watch")
+	}
+
+	return nil
+}
+
+// IsPasswordCorrect watch-only wallet has no password.
+func (wlt *WatchWallet) IsPasswordCorrect(passwd []byte) error {
+	return errWatchOnly
+}
+
+// Decryption watch-only wallet has no secrets.
+func (wlt *WatchWallet) Decryption(passwd []byte) error {
+	return errWatchOnly
+}
+
+// Encryption nothing to encrypt in watch-only wallet.
+func (wlt *WatchWallet) Encryption(passwd []byte) error {
+	return nil
+}
+
+// Unlock watch-only wallet has no secrets.
+func (wlt *WatchWallet) Unlock(passwd []byte) error {
+	return errWatchOnly
+}
+
+// NewAddresses watch-only wallet can't generate address.
+func (wlt *WatchWallet) NewAddresses(num int) ([]coin.AddressEntry, error) {
+	return []coin.AddressEntry{}, errWatchOnly
+}
+
+// ImportKey watch-only wallet can't hold secret key.
+func (wlt *WatchWallet) ImportKey(seckey []byte) (coin.AddressEntry, error) {
+	return coin.AddressEntry{}, errWatchOnly
+}
+
+// GetKeypair watch-only wallet has no seckey.
+func (wlt *WatchWallet) GetKeypair(addr string, passwd []byte) (string, string, error) {
+	return "", "", errWatchOnly
+}
+
+// GetSeckey watch-only wallet has no seckey.
+func (wlt *WatchWallet) GetSeckey(addr string, passwd []byte) ([]byte, error) {
+	return nil, errWatchOnly
+}
+
+// GetSeckeys watch-only wallet has no seckey.
+func (wlt *WatchWallet) GetSeckeys(addrs []string, passwd []byte) ([][]byte, error) {
+	return nil, errWatchOnly
+}
+
+// GetSeed watch-only wallet has no seed.
+func (wlt *WatchWallet) GetSeed(passwd []byte) (string, error) {
+	return "", errWatchOnly
+}
+
+// GetSeedBytes watch-only wallet has no seed.
+func (wlt *WatchWallet) GetSeedBytes(passwd []byte) ([]byte, error) {
+	return nil, errWatchOnly
+}
+
+// Save save the public fields of watch-only wallet.
+func (wlt *WatchWallet) Save(w io.Writer, passwd []byte) error {
+	d, err := json.MarshalIndent(wlt, "", "    ")
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, bytes.NewBuffer(d))
+	return err
+}
+
+// SaveCopy save the public fields of watch-only wallet.
+func (wlt *WatchWallet) SaveCopy(w io.Writer, passwd []byte) error {
+	return wlt.Save(w, passwd)
+}
+
+// Load load watch-only wallet from reader.
+func (wlt *WatchWallet) Load(r io.Reader) error {
+	if err := json.NewDecoder(r).Decode(wlt); err != nil {
+		return err
+	}
+	return wlt.Validate()
+}
+
+// Copy returns copy of self
+func (wlt *WatchWallet) Copy() Walleter {
+	return &WatchWallet{
+		Wallet: wlt.Wallet.Copy(),
+	}
+}
@@ -10,8 +10,8 @@ import (
 	"testing"
 	"time"
 
-	"github.com/spolabs/wallet-api/src/coin"
-	"github.com/spolabs/wallet-api/src/wallet"
+	"github.com/MDLlife/wallet-api/src/coin"
+	"github.com/MDLlife/wallet-api/src/wallet"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -93,7 +93,7 @@ func TestNewAddresses(t *testing.T) {
 
 	for _, d := range testData {
 		// new wallet
-		wlt, err := wallet.New(d.Type, d.Lable, d.Seed, password)
+		wlt, err := wallet.NewBrainWallet(d.Type, d.Lable, d.Seed, password)
 		assert.Nil(t, err)
 
 		// has 1 address default
@@ -172,7 +172,7 @@ func TestGetAddresses(t *testing.T) {
 
 	for _, d := range testData {
 		// new wallet
-		wlt, err := wallet.New(d.Type, d.Lable, d.Seed, password)
+		wlt, err := wallet.NewBrainWallet(d.Type, d.Lable, d.Seed, password)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -255,7 +255,7 @@ func TestGetKeypair(t *testing.T) {
 
 	for _, d := range testData {
 		// new wallet
-		wlt, err := wallet.New(d.Type, d.Lable, d.Seed, password)
+		wlt, err := wallet.NewBrainWallet(d.Type, d.Lable, d.Seed, password)
 		if err != nil {
 			t.Fatal(err)
 		}
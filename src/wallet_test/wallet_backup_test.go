@@ -0,0 +1,198 @@
+package wallet_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/MDLlife/wallet-api/src/coin/spo"
+	"github.com/MDLlife/wallet-api/src/util/encrypt"
+	"github.com/MDLlife/wallet-api/src/wallet"
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/assert"
+)
+
+// failStorage fails to write the wallet file of name.
+type failStorage struct {
+	wallet.Storage
+	name string
+}
+
+func (fs failStorage) Write(name string, d []byte) error {
+	if name == fs.name {
+		return errors.New("disk full")
+	}
+	return fs.Storage.Write(name, d)
+}
+
+func TestBackup(t *testing.T) {
+	wltDir, teardown, err := setup(t)
+	assert.Nil(t, err)
+	defer teardown()
+	wallet.Reset()
+
+	passwd := "12345678"
+	backupPasswd := "backup-passwd"
+	wlt, err := wallet.NewBrainWallet("spo", "l1", "seed1", passwd)
+	assert.NoError(t, err)
+	_, err = wallet.NewAddresses(wlt.GetID(), 1, passwd)
+	assert.NoError(t, err)
+	addrs, err := wallet.GetAddresses(wlt.GetID())
+	assert.NoError(t, err)
+	assert.NoError(t, wallet.SetAddressLabel(wlt.GetID(), addrs[1], "Savings"))
+	assert.NoError(t, wallet.Unlock(wlt.GetID(), passwd, time.Minute))
+	assert.NoError(t, wallet.SetLable(wlt.GetID(), "Main"))
+	assert.NoError(t, wallet.Lock(wlt.GetID()))
+
+	_, sec := cipher.GenerateDeterministicKeyPair([]byte("backup1"))
+	cwlt, err := wallet.NewCollection("spo", "c1", passwd)
+	assert.NoError(t, err)
+	caddr, err := wallet.ImportKey(cwlt.GetID(), sec.Hex(), passwd)
+	assert.NoError(t, err)
+	pub, _ := cipher.GenerateDeterministicKeyPair([]byte("backup2"))
+	wwlt, err := wallet.NewWatchWallet("spo", "w1", []string{pub.Hex()})
+	assert.NoError(t, err)
+	ids := []string{wlt.GetID(), cwlt.GetID(), wwlt.GetID()}
+	sort.Strings(ids)
+
+	_, err = wallet.ExportBackup(passwd, "")
+	assert.EqualError(t, err, "backup password cannot empty")
+	// the wallets can't be decrypted by passwd are skipped.
+	skipped := []string{wlt.GetID(), cwlt.GetID()}
+	sort.Strings(skipped)
+	exported, err := wallet.ExportBackup("87654321", backupPasswd)
+	assert.NoError(t, err)
+	assert.Equal(t, skipped, exported.Skipped)
+
+	// the unlocked wallet is exported by its session with empty passwd, other passwd
+	// must still be its password.
+	assert.NoError(t, wallet.Unlock(wlt.GetID(), passwd, time.Minute))
+	exported, err = wallet.ExportBackup("87654321", backupPasswd)
+	assert.NoError(t, err)
+	assert.Equal(t, skipped, exported.Skipped)
+	exported, err = wallet.ExportBackup("", backupPasswd)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{cwlt.GetID()}, exported.Skipped)
+	exported, err = wallet.ExportBackup(passwd, backupPasswd)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{}, exported.Skipped)
+	blob := exported.Backup
+	assert.True(t, wallet.IsUnlocked(wlt.GetID()))
+	assert.NotContains(t, blob, "seed1")
+	assert.NotContains(t, blob, "Savings")
+	assert.NoError(t, wallet.Lock(wlt.GetID()))
+
+	// restore on another device with another password.
+	newDir := filepath.Join(wltDir, "new")
+	assert.NoError(t, os.MkdirAll(newDir, 0700))
+	wallet.InitDir(newDir)
+	defer wallet.InitDir(wltDir)
+	_, err = wallet.LoadWallet()
+	assert.NoError(t, err)
+
+	newPasswd := "new-passwd"
+	_, err = wallet.ImportBackup(blob, "wrong", newPasswd)
+	assert.EqualError(t, err, "backup password incorrect or backup tampered")
+	tampered := strings.Replace(blob, `"data":"`, `"data":"A`, 1)
+	_, err = wallet.ImportBackup(tampered, backupPasswd, newPasswd)
+	assert.EqualError(t, err, "backup password incorrect or backup tampered")
+	_, err = wallet.ImportBackup("xx", backupPasswd, newPasswd)
+	assert.EqualError(t, err, "invalid backup")
+	_, err = wallet.ImportBackup(blob, backupPasswd, "")
+	assert.EqualError(t, err, "password cannot empty")
+
+	// the imported wallets are removed if one fails to save.
+	wallet.InitStorage(failStorage{Storage: wallet.NewMemoryStorage(), name: ids[2] + "." + wallet.Ext})
+	_, err = wallet.ImportBackup(blob, backupPasswd, newPasswd)
+	assert.EqualError(t, err, ids[2]+": disk full")
+	assert.Empty(t, wallet.ListWallets())
+	wallet.InitDir(newDir)
+	_, err = wallet.LoadWallet()
+	assert.NoError(t, err)
+
+	report, err := wallet.ImportBackup(blob, backupPasswd, newPasswd)
+	assert.NoError(t, err)
+	assert.Equal(t, wallet.ImportReport{Imported: ids, Conflicts: []string{}}, report)
+
+	wallet.Reset()
+	_, err = wallet.LoadWallet()
+	assert.NoError(t, err)
+	seed, err := wallet.GetSeed(wlt.GetID(), newPasswd)
+	assert.NoError(t, err)
+	assert.Equal(t, "seed1", seed)
+	_, err = wallet.GetSeed(wlt.GetID(), passwd)
+	assert.Error(t, err)
+	seckey, err := wallet.GetSeckey(cwlt.GetID(), caddr, newPasswd)
+	assert.NoError(t, err)
+	assert.Equal(t, sec[:], seckey)
+	entries, err := wallet.GetAddressEntries(wlt.GetID())
+	assert.NoError(t, err)
+	assert.Equal(t, "Savings", entries[1].Label)
+	assert.Equal(t, 1, entries[1].Index)
+	for _, info := range wallet.ListWallets() {
+		if info.ID == wlt.GetID() {
+			assert.Equal(t, "Main", info.Lable)
+		}
+	}
+	waddrs, err := wallet.GetAddresses(wwlt.GetID())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{cipher.AddressFromPubKey(pub).String()}, waddrs)
+
+	// the existing wallets are kept.
+	assert.NoError(t, wallet.Remove(cwlt.GetID()))
+	report, err = wallet.ImportBackup(blob, backupPasswd, "other-passwd")
+	assert.NoError(t, err)
+	conflicts := []string{wlt.GetID(), wwlt.GetID()}
+	sort.Strings(conflicts)
+	assert.Equal(t, wallet.ImportReport{Imported: []string{cwlt.GetID()}, Conflicts: conflicts}, report)
+	assert.NoError(t, wallet.VerifyPassword(wlt.GetID(), newPasswd))
+	assert.NoError(t, wallet.VerifyPassword(cwlt.GetID(), "other-passwd"))
+
+	// the version is checked.
+	fields := make(map[string]interface{})
+	assert.NoError(t, json.Unmarshal([]byte(blob), &fields))
+	fields["version"] = "9"
+	d, err := json.Marshal(fields)
+	assert.NoError(t, err)
+	_, err = wallet.ImportBackup(string(d), backupPasswd, newPasswd)
+	assert.EqualError(t, err, "backup version 9 not supported")
+
+	// the ids are used as file names, the ids out of the wallet dir are rejected.
+	var b struct {
+		Version string          `json:"version"`
+		Crypto  *encrypt.Params `json:"crypto"`
+		Data    string          `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(blob), &b))
+	data, err := encrypt.Decrypt([]byte(backupPasswd), b.Data, b.Crypto)
+	assert.NoError(t, err)
+	for _, id := range []string{
+		"spo_../../evil",
+		"spo_a/b",
+		`spo_a\b`,
+		"spo_..",
+		"spo_",
+		"spo",
+		"spo_a_b",
+		"watch_watch_a",
+		"btc_abc",
+	} {
+		bad := strings.Replace(data, `"`+wlt.GetID()+`"`, `"`+id+`"`, -1)
+		assert.NotEqual(t, data, bad, id)
+		enc, params, err := encrypt.Encrypt([]byte(backupPasswd), bad, b.Crypto.Options())
+		assert.NoError(t, err, id)
+		b.Data, b.Crypto = enc, params
+		d, err := json.Marshal(b)
+		assert.NoError(t, err, id)
+		_, err = wallet.ImportBackup(string(d), backupPasswd, newPasswd)
+		assert.Error(t, err, id)
+		assert.True(t, strings.HasSuffix(err.Error(), "invalid wallet id") || strings.HasSuffix(err.Error(), "wallet not supported"), id)
+	}
+	_, err = os.Stat(filepath.Join(wltDir, "evil."+wallet.Ext))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,80 @@
+package wallet_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/MDLlife/wallet-api/src/coin/spo"
+	"github.com/MDLlife/wallet-api/src/wallet"
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectionWallet(t *testing.T) {
+	_, teardown, err := setup(t)
+	assert.Nil(t, err)
+	defer teardown()
+	wallet.Reset()
+
+	passwd := "12345678"
+	pub1, sec1 := cipher.GenerateDeterministicKeyPair([]byte("key1"))
+	pub2, sec2 := cipher.GenerateDeterministicKeyPair([]byte("key2"))
+
+	_, err = wallet.NewCollection("btc", "c1", passwd)
+	assert.EqualError(t, err, "btc wallet not regestered")
+	wlt, err := wallet.NewCollection("spo", "c1", passwd)
+	assert.NoError(t, err)
+	id := wlt.GetID()
+	assert.True(t, strings.HasPrefix(id, "spo_"))
+	addrs, err := wallet.GetAddresses(id)
+	assert.NoError(t, err)
+	assert.Empty(t, addrs)
+
+	_, err = wallet.NewAddresses(id, 1, passwd)
+	assert.EqualError(t, err, "collection wallet has no seed, import keys instead")
+	_, err = wallet.ImportKey(id, "zz", passwd)
+	assert.EqualError(t, err, "invalid secret key")
+	_, err = wallet.ImportKey(id, sec1.Hex()[:62], passwd)
+	assert.EqualError(t, err, "invalid secret key")
+	_, err = wallet.ImportKey(id, sec1.Hex(), "87654321")
+	assert.EqualError(t, err, "wallet password incorrect")
+
+	addr, err := wallet.ImportKey(id, sec1.Hex(), passwd)
+	assert.NoError(t, err)
+	assert.Equal(t, cipher.AddressFromPubKey(pub1).String(), addr)
+	_, err = wallet.ImportKey(id, sec1.Hex(), passwd)
+	assert.EqualError(t, err, addr+" already exist")
+
+	// the unlocked wallet imports without password.
+	assert.NoError(t, wallet.Unlock(id, passwd, time.Minute))
+	addr2, err := wallet.ImportKey(id, sec2.Hex(), "")
+	assert.NoError(t, err)
+	assert.Equal(t, cipher.AddressFromPubKey(pub2).String(), addr2)
+	assert.NoError(t, wallet.Lock(id))
+
+	// deterministic wallet only holds keys of its seed.
+	dwlt, err := wallet.NewBrainWallet("spo", "l1", "seed1", passwd)
+	assert.NoError(t, err)
+	_, err = wallet.ImportKey(dwlt.GetID(), sec1.Hex(), passwd)
+	assert.EqualError(t, err, "keys can only be imported into collection wallet")
+
+	// keys are encrypted in the file and can be used for signing after reload.
+	assert.NoError(t, wallet.ChangePassword(id, passwd, "87654321"))
+	wallet.Reset()
+	report, err := wallet.LoadWallet()
+	assert.NoError(t, err)
+	assert.Contains(t, report.Loaded, id)
+	addrs, err = wallet.GetAddresses(id)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{addr, addr2}, addrs)
+	p, s, err := wallet.GetKeypair(id, addr2, "87654321")
+	assert.NoError(t, err)
+	assert.Equal(t, pub2.Hex(), p)
+	assert.Equal(t, sec2.Hex(), s)
+	seckey, err := wallet.GetSeckey(id, addr, "87654321")
+	assert.NoError(t, err)
+	assert.Equal(t, sec1[:], seckey)
+	_, _, err = wallet.GetKeypair(id, addr, passwd)
+	assert.Error(t, err)
+}
@@ -0,0 +1,79 @@
+package wallet_test
+
+import (
+	"strings"
+	"testing"
+
+	_ "github.com/MDLlife/wallet-api/src/coin/skycoin"
+	_ "github.com/MDLlife/wallet-api/src/coin/spo"
+	"github.com/MDLlife/wallet-api/src/wallet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewMultiCoinWallet(t *testing.T) {
+	_, teardown, err := setup(t)
+	assert.Nil(t, err)
+	defer teardown()
+	wallet.Reset()
+
+	passwd := "12345678"
+	mnemonic := strings.Repeat("abandon ", 11) + "about"
+
+	_, _, err = wallet.NewMultiCoinWallet("seed1", passwd, nil)
+	assert.EqualError(t, err, "invalid mnemonic, 1 words, must be 12, 15, 18, 21 or 24 words")
+	_, _, err = wallet.NewMultiCoinWallet(mnemonic, passwd, []string{"spo", "btc"})
+	assert.EqualError(t, err, "btc wallet not regestered")
+	_, _, err = wallet.NewMultiCoinWallet(mnemonic, passwd, []string{"spo", "spo"})
+	assert.EqualError(t, err, "duplicate coin type spo")
+	_, _, err = wallet.NewMultiCoinWallet(mnemonic, passwd, []string{"spo", wallet.WatchType})
+	assert.EqualError(t, err, "watch wallet not regestered")
+	assert.Empty(t, wallet.ListWallets())
+
+	group, wlts, err := wallet.NewMultiCoinWallet(mnemonic, passwd, []string{"spo", "skycoin"})
+	assert.NoError(t, err)
+	assert.Equal(t, wallet.MakeWltID("group", mnemonic), group)
+	assert.Len(t, wlts, 2)
+	for i, tp := range []string{"spo", "skycoin"} {
+		assert.Equal(t, wallet.MakeWltID(tp, mnemonic), wlts[i].GetID())
+		assert.Equal(t, tp, wlts[i].GetLable())
+		assert.Equal(t, group, wlts[i].GetGroup())
+		seed, err := wallet.GetSeed(wlts[i].GetID(), passwd)
+		assert.NoError(t, err)
+		assert.Equal(t, mnemonic, seed)
+	}
+	assert.Equal(t, []string{wallet.MakeWltID("skycoin", mnemonic), wallet.MakeWltID("spo", mnemonic)}, wallet.GetGroup(group))
+
+	// the existing wallet isn't overwritten.
+	_, _, err = wallet.NewMultiCoinWallet(mnemonic, passwd, []string{"spo"})
+	assert.EqualError(t, err, wallet.MakeWltID("spo", mnemonic)+" already exist")
+
+	// the group is kept in the file and covered by the mac.
+	wallet.Reset()
+	report, err := wallet.LoadWallet()
+	assert.NoError(t, err)
+	assert.Len(t, report.Loaded, 2)
+	assert.Len(t, wallet.GetGroup(group), 2)
+	assert.NoError(t, wallet.VerifyPassword(wlts[0].GetID(), passwd))
+	for _, info := range wallet.ListWallets() {
+		assert.Equal(t, group, info.Group)
+	}
+
+	// other wallets aren't removed with the group.
+	other, err := wallet.NewBrainWallet("spo", "l1", "seed1", passwd)
+	assert.NoError(t, err)
+	assert.NoError(t, wallet.Remove(wlts[1].GetID()))
+	assert.Empty(t, wallet.GetGroup(group))
+	assert.False(t, wallet.IsExist(wlts[0].GetID()))
+	assert.True(t, wallet.IsExist(other.GetID()))
+	wallet.Reset()
+	report, err = wallet.LoadWallet()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{other.GetID()}, report.Loaded)
+
+	// restored with the same seed, every registered coin by default.
+	group2, wlts, err := wallet.NewMultiCoinWallet(" "+mnemonic+"\n", passwd, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, group, group2)
+	assert.True(t, len(wlts) >= 2)
+	assert.Len(t, wallet.GetGroup(group), len(wlts))
+}
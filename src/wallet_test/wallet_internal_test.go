@@ -4,12 +4,14 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
-	_ "github.com/spolabs/wallet-api/src/coin/skycoin"
-	_ "github.com/spolabs/wallet-api/src/coin/spo"
-	"github.com/spolabs/wallet-api/src/wallet"
+	_ "github.com/MDLlife/wallet-api/src/coin/skycoin"
+	_ "github.com/MDLlife/wallet-api/src/coin/spo"
+	"github.com/MDLlife/wallet-api/src/wallet"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/unicode/norm"
 )
 
 func TestNewWallet(t *testing.T) {
@@ -40,7 +42,7 @@ func TestNewWallet(t *testing.T) {
 	}
 
 	for _, d := range testData {
-		wlt, err := wallet.New(d.Type, d.Lable, d.Seed, d.Passwd)
+		wlt, err := wallet.NewBrainWallet(d.Type, d.Lable, d.Seed, d.Passwd)
 		if err != nil {
 			fmt.Println(d.Type, " ", d.Lable, " ", d.Seed, " ", d.Passwd)
 			t.Error(err)
@@ -54,7 +56,9 @@ func TestNewWallet(t *testing.T) {
 		assert.Equal(t, wlt.GetType(), d.Type)
 		// default address number 1
 		assert.Equal(t, len(wlt.GetAddresses()), 1)
-		assert.Equal(t, wlt.GetSeed(password), d.Seed)
+		seed, err := wlt.GetSeed([]byte(password))
+		assert.NoError(t, err)
+		assert.Equal(t, d.Seed, seed)
 
 		walletFile := filepath.Join(tmpDir, (wlt.GetID() + "." + wallet.Ext))
 		if _, err := os.Stat(walletFile); os.IsNotExist(err) {
@@ -63,9 +67,138 @@ func TestNewWallet(t *testing.T) {
 		}
 	}
 
-	err := wallet.LoadWallet(password)
+	_, err := wallet.LoadWallet()
 	assert.NoError(t, err)
 	dir := wallet.GetWalletDir()
 	assert.Equal(t, dir, tmpDir)
 
 }
+
+func TestNewMnemonicWallet(t *testing.T) {
+	_, teardown, err := setup(t)
+	assert.Nil(t, err)
+	defer teardown()
+	wallet.Reset()
+
+	password := "walletpasswd"
+	mnemonic := strings.Repeat("abandon ", 11) + "about"
+
+	testData := []struct {
+		Seed string
+		Err  string
+	}{
+		{"seed1", "invalid mnemonic, 1 words, must be 12, 15, 18, 21 or 24 words"},
+		{strings.Repeat("abandon ", 10) + "abandonn about", "invalid mnemonic word 11: abandonn"},
+		{strings.Repeat("abandon ", 11) + "About", "invalid mnemonic word 12: About"},
+		{strings.Repeat("abandon ", 12), "invalid mnemonic, checksum failed"},
+	}
+	for _, d := range testData {
+		assert.EqualError(t, wallet.ValidateMnemonic(d.Seed), d.Err)
+		_, err := wallet.New("spo", "l1", d.Seed, password)
+		assert.EqualError(t, err, d.Err)
+	}
+
+	// words separated by other spaces are the same mnemonic.
+	wlt, err := wallet.New("spo", "l1", " "+strings.Replace(mnemonic, " ", "  \n", 3), password)
+	assert.NoError(t, err)
+	assert.Equal(t, wallet.MakeWltID("spo", mnemonic), wlt.GetID())
+	seed, err := wallet.GetSeed(wlt.GetID(), password)
+	assert.NoError(t, err)
+	assert.Equal(t, mnemonic, seed)
+
+	// the composed and decomposed accents are the same words, the seed is kept in NFKD.
+	var spanish string
+	for i := 0; i < 100 && norm.NFC.String(spanish) == norm.NFKD.String(spanish); i++ {
+		spanish, err = wallet.NewSeedWithOptions(128, wallet.LangSpanish)
+		assert.NoError(t, err)
+	}
+	composed, decomposed := norm.NFC.String(spanish), norm.NFKD.String(spanish)
+	assert.NotEqual(t, composed, decomposed)
+	for _, m := range []string{composed, decomposed} {
+		lang, err := wallet.MnemonicLanguage(m)
+		assert.NoError(t, err)
+		assert.Equal(t, wallet.LangSpanish, lang)
+	}
+	wlt, err = wallet.New("spo", "l4", composed, password)
+	assert.NoError(t, err)
+	assert.Equal(t, wallet.MakeWltID("spo", decomposed), wlt.GetID())
+	seed, err = wallet.GetSeed(wlt.GetID(), password)
+	assert.NoError(t, err)
+	assert.Equal(t, decomposed, seed)
+	_, err = wallet.New("spo", "l5", decomposed, password)
+	assert.EqualError(t, err, wlt.GetID()+" already exist")
+
+	// random mnemonic is generated for empty seed.
+	wlt, err = wallet.New("spo", "l2", "", password)
+	assert.NoError(t, err)
+	seed, err = wallet.GetSeed(wlt.GetID(), password)
+	assert.NoError(t, err)
+	assert.NoError(t, wallet.ValidateMnemonic(seed))
+
+	// brain wallet opts in arbitrary seed.
+	wlt, err = wallet.NewBrainWallet("spo", "l3", "seed1", password)
+	assert.NoError(t, err)
+	assert.Equal(t, wallet.MakeWltID("spo", "seed1"), wlt.GetID())
+}
+
+func TestNewSeedWithOptions(t *testing.T) {
+	_, teardown, err := setup(t)
+	assert.Nil(t, err)
+	defer teardown()
+	wallet.Reset()
+
+	languages := []string{
+		wallet.LangEnglish,
+		wallet.LangChineseSimplified,
+		wallet.LangChineseTraditional,
+		wallet.LangJapanese,
+		wallet.LangSpanish,
+		wallet.LangFrench,
+		wallet.LangItalian,
+		wallet.LangKorean,
+	}
+	for _, lang := range languages {
+		for _, bits := range []int{128, 160, 192, 224, 256} {
+			seed, err := wallet.NewSeedWithOptions(bits, lang)
+			assert.NoError(t, err)
+			assert.Len(t, strings.Fields(seed), bits/32*3)
+			detected, err := wallet.MnemonicLanguage(seed)
+			assert.NoError(t, err, seed)
+			// the chinese wordlists share words.
+			if strings.HasPrefix(lang, "chinese") {
+				assert.True(t, strings.HasPrefix(detected, "chinese"))
+			} else {
+				assert.Equal(t, lang, detected)
+			}
+		}
+	}
+
+	_, err = wallet.NewSeedWithOptions(100, wallet.LangEnglish)
+	assert.EqualError(t, err, "entropy bits must be 128, 160, 192, 224 or 256")
+	_, err = wallet.NewSeedWithOptions(128, "klingon")
+	assert.EqualError(t, err, "klingon wordlist not supported")
+	seed, err := wallet.NewSeedWithOptions(128, "")
+	assert.NoError(t, err)
+	lang, err := wallet.MnemonicLanguage(seed)
+	assert.NoError(t, err)
+	assert.Equal(t, wallet.LangEnglish, lang)
+
+	// bip39 test vectors.
+	assert.NoError(t, wallet.ValidateMnemonic(strings.Repeat("zoo ", 11)+"wrong"))
+	assert.NoError(t, wallet.ValidateMnemonic(strings.Repeat("zoo ", 23)+"vote"))
+	assert.NoError(t, wallet.ValidateMnemonic("legal winner thank year wave sausage worth useful legal winner thank yellow"))
+	assert.EqualError(t, wallet.ValidateMnemonic(strings.Repeat("zoo ", 23)+"zoo"), "invalid mnemonic, checksum failed")
+
+	// restore detects the language.
+	seed, err = wallet.NewSeedWithOptions(256, wallet.LangJapanese)
+	assert.NoError(t, err)
+	wlt, err := wallet.New("spo", "l1", seed, "12345678")
+	assert.NoError(t, err)
+	sd, err := wallet.GetSeed(wlt.GetID(), "12345678")
+	assert.NoError(t, err)
+	assert.Equal(t, seed, sd)
+	words := strings.Fields(seed)
+	words[3] = "zoo"
+	_, err = wallet.New("spo", "l2", strings.Join(words, " "), "12345678")
+	assert.EqualError(t, err, "invalid mnemonic word 4: zoo")
+}
@@ -0,0 +1,68 @@
+package wallet_test
+
+import (
+	"sort"
+	"testing"
+
+	_ "github.com/MDLlife/wallet-api/src/coin/spo"
+	"github.com/MDLlife/wallet-api/src/wallet"
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListWallets(t *testing.T) {
+	_, teardown, err := setup(t)
+	assert.Nil(t, err)
+	defer teardown()
+	wallet.Reset()
+
+	assert.Empty(t, wallet.ListWallets())
+
+	passwd := "12345678"
+	wlt1, err := wallet.NewBrainWallet("spo", "l1", "seed1", passwd)
+	assert.NoError(t, err)
+	_, err = wallet.NewAddresses(wlt1.GetID(), 2, passwd)
+	assert.NoError(t, err)
+	wlt2, err := wallet.NewCollection("spo", "c1", passwd)
+	assert.NoError(t, err)
+	pub, _ := cipher.GenerateDeterministicKeyPair([]byte("list1"))
+	wlt3, err := wallet.NewWatchWallet("spo", "w1", []string{pub.Hex()})
+	assert.NoError(t, err)
+
+	expect := []wallet.WalletInfo{
+		{ID: wlt1.GetID(), Type: "spo", Lable: "l1", Tm: wlt1.GetTime(), AddressCount: 3, Version: wallet.WalletVersion, WalletType: wallet.WalletType, Verified: true},
+		{ID: wlt2.GetID(), Type: "spo", Lable: "c1", Tm: wlt2.GetTime(), AddressCount: 0, Version: wallet.WalletVersion, WalletType: wallet.CollectionWalletType, Verified: true},
+		{ID: wlt3.GetID(), Type: "spo", Lable: "w1", Tm: wlt3.GetTime(), AddressCount: 1, Version: wallet.WalletVersion, WalletType: wallet.WatchWalletType},
+	}
+	// sorted by id.
+	sort.Slice(expect, func(i, j int) bool { return expect[i].ID < expect[j].ID })
+	assert.Equal(t, expect, wallet.ListWallets())
+
+	// the lable is changed without password.
+	assert.NoError(t, wallet.SetLable(wlt1.GetID(), "Savings"))
+	assert.False(t, wallet.IsUnlocked(wlt1.GetID()))
+	assert.NoError(t, wallet.SetLable(wlt3.GetID(), "From Alice"))
+	assert.EqualError(t, wallet.SetLable("spo_none", "x"), "spo_none wallet does not exist")
+
+	wallet.Reset()
+	report, err := wallet.LoadWallet()
+	assert.NoError(t, err)
+	assert.Len(t, report.Loaded, 3)
+	lables := make(map[string]string)
+	for _, info := range wallet.ListWallets() {
+		lables[info.ID] = info.Lable
+	}
+	assert.Equal(t, map[string]string{wlt1.GetID(): "Savings", wlt2.GetID(): "c1", wlt3.GetID(): "From Alice"}, lables)
+	// the lable isn't covered by the mac.
+	assert.NoError(t, wallet.VerifyPassword(wlt1.GetID(), passwd))
+	// the loaded wallets are verified by the password.
+	for _, info := range wallet.ListWallets() {
+		assert.False(t, info.Verified)
+	}
+	seed, err := wallet.GetSeed(wlt1.GetID(), passwd)
+	assert.NoError(t, err)
+	assert.Equal(t, "seed1", seed)
+	for _, info := range wallet.ListWallets() {
+		assert.Equal(t, info.ID == wlt1.GetID(), info.Verified)
+	}
+}
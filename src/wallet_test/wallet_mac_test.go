@@ -0,0 +1,296 @@
+package wallet_test
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MDLlife/wallet-api/src/coin/skycoin"
+	_ "github.com/MDLlife/wallet-api/src/coin/spo"
+	"github.com/MDLlife/wallet-api/src/util/encrypt"
+	"github.com/MDLlife/wallet-api/src/wallet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalletMac(t *testing.T) {
+	wltDir, teardown, err := setup(t)
+	assert.Nil(t, err)
+	defer teardown()
+	wallet.Reset()
+
+	passwd := "12345678"
+	wlt, err := wallet.NewBrainWallet("spo", "l1", "seed1", passwd)
+	assert.NoError(t, err)
+	_, err = wallet.NewAddresses(wlt.GetID(), 1, passwd)
+	assert.NoError(t, err)
+
+	path := filepath.Join(wltDir, wlt.GetID()+"."+wallet.Ext)
+	cnt, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+
+	testData := []struct {
+		Name   string
+		Modify func(fields map[string]interface{})
+	}{
+		{"tm", func(fields map[string]interface{}) { fields["tm"] = "1" }},
+		{"entries", func(fields map[string]interface{}) {
+			entries := fields["entries"].([]interface{})
+			fields["entries"] = append(entries, map[string]interface{}{
+				"address": "2GgFvqoyk9RjwVzj8tqfcXVXB4orBwoc9qv",
+				"pubkey":  "",
+				"seckey":  "",
+			})
+		}},
+		{"address", func(fields map[string]interface{}) {
+			entry := fields["entries"].([]interface{})[0].(map[string]interface{})
+			entry["address"] = "2GgFvqoyk9RjwVzj8tqfcXVXB4orBwoc9qv"
+		}},
+		{"mac removed", func(fields map[string]interface{}) { delete(fields, "mac") }},
+	}
+
+	for _, d := range testData {
+		fields := make(map[string]interface{})
+		assert.NoError(t, json.Unmarshal(cnt, &fields), d.Name)
+		d.Modify(fields)
+		tampered, err := json.MarshalIndent(fields, "", "    ")
+		assert.NoError(t, err, d.Name)
+		assert.NoError(t, ioutil.WriteFile(path, tampered, 0600), d.Name)
+
+		wallet.Reset()
+		_, err = wallet.LoadWallet()
+		assert.NoError(t, err, d.Name)
+		err = wallet.VerifyPassword(wlt.GetID(), passwd)
+		assert.EqualError(t, err, "wallet file tampered, public fields do not match the mac", d.Name)
+		_, err = wallet.GetSeed(wlt.GetID(), passwd)
+		assert.Error(t, err, d.Name)
+		_, err = wallet.NewAddresses(wlt.GetID(), 1, passwd)
+		assert.Error(t, err, d.Name)
+	}
+
+	// the lable and the metadata of entries are excluded from the mac on purpose.
+	excluded := []struct {
+		Name   string
+		Modify func(fields map[string]interface{})
+	}{
+		{"lable", func(fields map[string]interface{}) { fields["lable"] = "l2" }},
+		{"entry metadata", func(fields map[string]interface{}) {
+			entry := fields["entries"].([]interface{})[1].(map[string]interface{})
+			entry["label"] = "changed"
+			entry["index"] = 5
+			entry["created"] = 1
+			entry["used"] = true
+		}},
+	}
+	for _, d := range excluded {
+		fields := make(map[string]interface{})
+		assert.NoError(t, json.Unmarshal(cnt, &fields), d.Name)
+		d.Modify(fields)
+		modified, err := json.MarshalIndent(fields, "", "    ")
+		assert.NoError(t, err, d.Name)
+		assert.NoError(t, ioutil.WriteFile(path, modified, 0600), d.Name)
+
+		wallet.Reset()
+		_, err = wallet.LoadWallet()
+		assert.NoError(t, err, d.Name)
+		assert.NoError(t, wallet.VerifyPassword(wlt.GetID(), passwd), d.Name)
+	}
+
+	// the downgraded version doesn't make the covered fields unchecked, the version isn't
+	// covered, but the migrations take the lable under the mac again.
+	for _, version := range []string{"0.2", "0.4"} {
+		fields := make(map[string]interface{})
+		assert.NoError(t, json.Unmarshal(cnt, &fields))
+		fields["version"] = version
+		fields["tm"] = "1"
+		tampered, err := json.MarshalIndent(fields, "", "    ")
+		assert.NoError(t, err)
+		assert.NoError(t, ioutil.WriteFile(path, tampered, 0600))
+		wallet.Reset()
+		_, err = wallet.LoadWallet()
+		assert.NoError(t, err)
+		assert.EqualError(t, wallet.VerifyPassword(wlt.GetID(), passwd), "wallet file tampered, public fields do not match the mac", version)
+	}
+
+	// wrong password is still reported as it is.
+	assert.EqualError(t, wallet.VerifyPassword(wlt.GetID(), "87654321"), "wallet password incorrect")
+
+	assert.NoError(t, ioutil.WriteFile(path, cnt, 0600))
+	wallet.Reset()
+	_, err = wallet.LoadWallet()
+	assert.NoError(t, err)
+	seed, err := wallet.GetSeed(wlt.GetID(), passwd)
+	assert.NoError(t, err)
+	assert.Equal(t, "seed1", seed)
+}
+
+func TestWalletMacPending(t *testing.T) {
+	wltDir, teardown, err := setup(t)
+	assert.Nil(t, err)
+	defer teardown()
+	wallet.Reset()
+
+	// the wallet file of 0.2 has no mac, its secrets have no mac key.
+	passwd := "12345678"
+	w := &skycoin.Wallet{Wallet: wallet.Wallet{Type: "spo"}}
+	w.SetConstant()
+	w.SetID(wallet.MakeWltID("spo", "seed1"))
+	w.SetSeed("seed1")
+	_, err = w.NewAddresses(1)
+	assert.NoError(t, err)
+	nextSeed := string(w.Seed)
+	addr := w.GetAddresses()[0]
+	var buf bytes.Buffer
+	assert.NoError(t, w.Save(&buf, []byte(passwd)))
+	_, sec, err := w.GetKeypair(addr, []byte(passwd))
+	assert.NoError(t, err)
+
+	secrets, err := json.Marshal(map[string]string{"seed": nextSeed, "init_seed": "seed1", addr: sec})
+	assert.NoError(t, err)
+	encry, params, err := encrypt.Encrypt([]byte(passwd), string(secrets), encrypt.Options{N: 1 << 10})
+	assert.NoError(t, err)
+	fields := make(map[string]interface{})
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &fields))
+	fields["secrets"] = encry
+	fields["crypto"] = params
+	delete(fields, "mac")
+
+	path := filepath.Join(wltDir, w.GetID()+"."+wallet.Ext)
+	write := func(version string, modify func(fields map[string]interface{})) {
+		fields["version"] = version
+		modify(fields)
+		d, err := json.MarshalIndent(fields, "", "    ")
+		assert.NoError(t, err)
+		assert.NoError(t, ioutil.WriteFile(path, d, 0600))
+		wallet.Reset()
+		_, err = wallet.LoadWallet()
+		assert.NoError(t, err)
+	}
+
+	// the mac key missing from the wallet of current version is tampering.
+	write(wallet.WalletVersion, func(fields map[string]interface{}) {})
+	assert.EqualError(t, wallet.VerifyPassword(w.GetID(), passwd), "wallet file tampered, public fields do not match the mac")
+	assert.Error(t, wallet.Unlock(w.GetID(), passwd, time.Minute))
+
+	// the migrated wallet is unverified until the mac is added on unlock.
+	write("0.2", func(fields map[string]interface{}) {})
+	cnt, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(cnt), `"mac_pending": true`)
+	assert.False(t, wallet.ListWallets()[0].Verified)
+	assert.NoError(t, wallet.VerifyPassword(w.GetID(), passwd))
+	assert.False(t, wallet.ListWallets()[0].Verified)
+
+	assert.NoError(t, wallet.Unlock(w.GetID(), passwd, time.Minute))
+	assert.True(t, wallet.ListWallets()[0].Verified)
+	cnt, err = ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(cnt), "mac_pending")
+	assert.Contains(t, string(cnt), `"mac"`)
+	seed, err := wallet.GetSeed(w.GetID(), "")
+	assert.NoError(t, err)
+	assert.Equal(t, "seed1", seed)
+
+	// the flag doesn't disable the mac once the secrets have the mac key.
+	fields = make(map[string]interface{})
+	assert.NoError(t, json.Unmarshal(cnt, &fields))
+	write(wallet.WalletVersion, func(fields map[string]interface{}) {
+		fields["mac_pending"] = true
+		fields["tm"] = "1"
+	})
+	assert.False(t, wallet.ListWallets()[0].Verified)
+	assert.EqualError(t, wallet.VerifyPassword(w.GetID(), passwd), "wallet file tampered, public fields do not match the mac")
+}
+
+func TestWalletMacLable(t *testing.T) {
+	wltDir, teardown, err := setup(t)
+	assert.Nil(t, err)
+	defer teardown()
+	wallet.Reset()
+
+	// the wallet file of 0.4 has the mac which covers the lable.
+	passwd := "12345678"
+	w := &skycoin.Wallet{Wallet: wallet.Wallet{Type: "spo", Lable: "l1"}}
+	w.SetConstant()
+	w.SetTime("1532312341")
+	w.SetID(wallet.MakeWltID("spo", "seed1"))
+	w.SetSeed("seed1")
+	_, err = w.NewAddresses(1)
+	assert.NoError(t, err)
+	nextSeed := string(w.Seed)
+	entry := w.GetAddressEntries()[0]
+	var buf bytes.Buffer
+	assert.NoError(t, w.Save(&buf, []byte(passwd)))
+	_, sec, err := w.GetKeypair(entry.Address, []byte(passwd))
+	assert.NoError(t, err)
+
+	macKey := []byte("0123456789abcdef0123456789abcdef")
+	secrets, err := json.Marshal(map[string]string{"seed": nextSeed, "init_seed": "seed1", entry.Address: sec, "mac_key": hex.EncodeToString(macKey)})
+	assert.NoError(t, err)
+	encry, params, err := encrypt.Encrypt([]byte(passwd), string(secrets), encrypt.Options{N: 1 << 10})
+	assert.NoError(t, err)
+	type macEntry struct {
+		Address string `json:"address"`
+		Public  string `json:"pubkey"`
+	}
+	d, err := json.Marshal(struct {
+		ID         string          `json:"id"`
+		Lable      string          `json:"lable"`
+		Entries    []macEntry      `json:"entries"`
+		Type       string          `json:"type"`
+		Tm         string          `json:"tm"`
+		WalletType string          `json:"wallet_type"`
+		Crypto     *encrypt.Params `json:"crypto"`
+	}{w.GetID(), "l1", []macEntry{{entry.Address, entry.Public}}, "spo", "1532312341", wallet.WalletType, params})
+	assert.NoError(t, err)
+	h := hmac.New(sha256.New, macKey)
+	h.Write(d)
+
+	fields := make(map[string]interface{})
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &fields))
+	fields["version"] = "0.4"
+	fields["secrets"] = encry
+	fields["crypto"] = params
+	fields["mac"] = hex.EncodeToString(h.Sum(nil))
+	path := filepath.Join(wltDir, w.GetID()+"."+wallet.Ext)
+	write := func(fields map[string]interface{}) {
+		d, err := json.MarshalIndent(fields, "", "    ")
+		assert.NoError(t, err)
+		assert.NoError(t, ioutil.WriteFile(path, d, 0600))
+		wallet.Reset()
+		_, err = wallet.LoadWallet()
+		assert.NoError(t, err)
+	}
+
+	// the migrated wallet keeps the lable of the mac aside, its lable is changed freely.
+	write(fields)
+	cnt, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(cnt), `"mac_lable": "l1"`)
+	assert.NoError(t, wallet.SetLable(w.GetID(), "Savings"))
+	assert.NoError(t, wallet.VerifyPassword(w.GetID(), passwd))
+
+	// the mac is renewed without the lable on unlock.
+	assert.NoError(t, wallet.Unlock(w.GetID(), passwd, time.Minute))
+	assert.NoError(t, wallet.Lock(w.GetID()))
+	cnt, err = ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(cnt), "mac_lable")
+	assert.Contains(t, string(cnt), `"lable": "Savings"`)
+	wallet.Reset()
+	_, err = wallet.LoadWallet()
+	assert.NoError(t, err)
+	assert.NoError(t, wallet.SetLable(w.GetID(), "l2"))
+	assert.NoError(t, wallet.VerifyPassword(w.GetID(), passwd))
+
+	// the lable kept aside is still covered by the mac.
+	fields["version"] = wallet.WalletVersion
+	fields["mac_lable"] = "l2"
+	write(fields)
+	assert.EqualError(t, wallet.VerifyPassword(w.GetID(), passwd), "wallet file tampered, public fields do not match the mac")
+}
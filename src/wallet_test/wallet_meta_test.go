@@ -0,0 +1,113 @@
+package wallet_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/MDLlife/wallet-api/src/coin/spo"
+	"github.com/MDLlife/wallet-api/src/wallet"
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddressMeta(t *testing.T) {
+	wltDir, teardown, err := setup(t)
+	assert.Nil(t, err)
+	defer teardown()
+	wallet.Reset()
+
+	passwd := "12345678"
+	wlt, err := wallet.NewBrainWallet("spo", "l1", "seed1", passwd)
+	assert.NoError(t, err)
+	id := wlt.GetID()
+	_, err = wallet.NewAddresses(id, 2, passwd)
+	assert.NoError(t, err)
+
+	entries, err := wallet.GetAddressEntries(id)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 3)
+	for i, e := range entries {
+		assert.Equal(t, i, e.Index)
+		assert.True(t, e.Created > 0)
+		assert.Empty(t, e.Label)
+		assert.False(t, e.Used)
+	}
+
+	// metadata is set without password and kept in the unlock session.
+	assert.NoError(t, wallet.Unlock(id, passwd, time.Minute))
+	assert.NoError(t, wallet.SetAddressLabel(id, entries[0].Address, "Savings"))
+	assert.NoError(t, wallet.SetAddressesUsed(id, map[string]bool{entries[0].Address: true, entries[2].Address: true}))
+	assert.EqualError(t, wallet.SetAddressLabel(id, "badaddr", "x"), "badaddr addr does not exist in wallet")
+	assert.EqualError(t, wallet.SetAddressLabel("spo_none", entries[0].Address, "x"), "spo_none wallet does not exist")
+	assert.True(t, wallet.IsUnlocked(id))
+	_, err = wallet.NewAddresses(id, 1, "")
+	assert.NoError(t, err)
+	assert.NoError(t, wallet.Lock(id))
+
+	// the metadata isn't covered by the mac, the wallet is loaded as usual.
+	wallet.Reset()
+	report, err := wallet.LoadWallet()
+	assert.NoError(t, err)
+	assert.Contains(t, report.Loaded, id)
+	entries, err = wallet.GetAddressEntries(id)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 4)
+	assert.Equal(t, "Savings", entries[0].Label)
+	assert.Equal(t, []bool{true, false, true, false}, []bool{entries[0].Used, entries[1].Used, entries[2].Used, entries[3].Used})
+	assert.Equal(t, 3, entries[3].Index)
+	seed, err := wallet.GetSeed(id, passwd)
+	assert.NoError(t, err)
+	assert.Equal(t, "seed1", seed)
+
+	// imported and watched addresses have no derivation index.
+	_, sec := cipher.GenerateDeterministicKeyPair([]byte("meta1"))
+	pub, _ := cipher.GenerateDeterministicKeyPair([]byte("meta2"))
+	cwlt, err := wallet.NewCollection("spo", "c1", passwd)
+	assert.NoError(t, err)
+	_, err = wallet.ImportKey(cwlt.GetID(), sec.Hex(), passwd)
+	assert.NoError(t, err)
+	wwlt, err := wallet.NewWatchWallet("spo", "w1", []string{pub.Hex()})
+	assert.NoError(t, err)
+	assert.NoError(t, wallet.SetAddressLabel(wwlt.GetID(), cipher.AddressFromPubKey(pub).String(), "From Alice"))
+	for _, id := range []string{cwlt.GetID(), wwlt.GetID()} {
+		entries, err := wallet.GetAddressEntries(id)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1)
+		assert.Equal(t, -1, entries[0].Index)
+		assert.True(t, entries[0].Created > 0)
+	}
+
+	// wallet of version 0.3 has no metadata.
+	path := filepath.Join(wltDir, id+"."+wallet.Ext)
+	cnt, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	fields := make(map[string]interface{})
+	assert.NoError(t, json.Unmarshal(cnt, &fields))
+	fields["version"] = "0.3"
+	for _, e := range fields["entries"].([]interface{}) {
+		m := e.(map[string]interface{})
+		delete(m, "label")
+		delete(m, "index")
+		delete(m, "created")
+		delete(m, "used")
+	}
+	old, err := json.MarshalIndent(fields, "", "    ")
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(path, old, 0600))
+
+	wallet.Reset()
+	report, err = wallet.LoadWallet()
+	assert.NoError(t, err)
+	assert.Contains(t, report.Loaded, id)
+	entries, err = wallet.GetAddressEntries(id)
+	assert.NoError(t, err)
+	for i, e := range entries {
+		assert.Equal(t, i, e.Index)
+		assert.True(t, e.Created > 0)
+	}
+	_, err = wallet.NewAddresses(id, 1, passwd)
+	assert.NoError(t, err)
+}
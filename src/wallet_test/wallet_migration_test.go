@@ -0,0 +1,116 @@
+package wallet_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/MDLlife/wallet-api/src/coin/spo"
+	"github.com/MDLlife/wallet-api/src/wallet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrateWalletFile(t *testing.T) {
+	wltDir, teardown, err := setup(t)
+	assert.Nil(t, err)
+	defer teardown()
+	wallet.Reset()
+
+	passwd := "12345678"
+	wlt, err := wallet.NewBrainWallet("spo", "l1", "seed1", passwd)
+	assert.NoError(t, err)
+
+	// downgrade the file to version 0.1
+	path := filepath.Join(wltDir, wlt.GetID()+"."+wallet.Ext)
+	cnt, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	old := strings.Replace(string(cnt), `"version": "`+wallet.WalletVersion+`"`, `"version": "0.1"`, 1)
+	assert.NotEqual(t, string(cnt), old)
+	assert.NoError(t, ioutil.WriteFile(path, []byte(old), 0600))
+
+	wallet.Reset()
+	_, err = wallet.LoadWallet()
+	assert.NoError(t, err)
+
+	cnt, err = ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(cnt), `"version": "`+wallet.WalletVersion+`"`)
+
+	// original file is kept
+	bak, err := ioutil.ReadFile(path + ".0.1.bak")
+	assert.NoError(t, err)
+	assert.Equal(t, old, string(bak))
+
+	seed, err := wallet.GetSeed(wlt.GetID(), passwd)
+	assert.NoError(t, err)
+	assert.Equal(t, "seed1", seed)
+	_, err = wallet.NewAddresses(wlt.GetID(), 1, passwd)
+	assert.NoError(t, err)
+
+	// unknown version
+	unknown := strings.Replace(string(cnt), `"version": "`+wallet.WalletVersion+`"`, `"version": "9.9"`, 1)
+	assert.NoError(t, ioutil.WriteFile(path, []byte(unknown), 0600))
+	wallet.Reset()
+	report, err := wallet.LoadWallet()
+	assert.NoError(t, err)
+	assert.Equal(t, []wallet.LoadFailure{{Name: wlt.GetID() + "." + wallet.Ext, Reason: "wallet version 9.9 not supported"}}, report.Skipped)
+	assert.False(t, wallet.IsExist(wlt.GetID()))
+}
+
+func TestRegisterMigration(t *testing.T) {
+	err := wallet.RegisterMigration(wallet.Migration{
+		From:    "0.1",
+		To:      "0.2",
+		Migrate: func(fields map[string]interface{}) error { return nil },
+	})
+	assert.EqualError(t, err, "migration of version 0.1 already registered")
+}
+
+func TestRemoveMigratedWallet(t *testing.T) {
+	wltDir, teardown, err := setup(t)
+	assert.Nil(t, err)
+	defer teardown()
+
+	boltStorage, err := wallet.NewBoltStorage(filepath.Join(wltDir, "wallets.db"))
+	assert.NoError(t, err)
+
+	testData := []struct {
+		Name    string
+		Storage wallet.Storage
+	}{
+		{"file", wallet.NewFileStorage(wltDir)},
+		{"memory", wallet.NewMemoryStorage()},
+		{"bolt", boltStorage},
+	}
+
+	passwd := "12345678"
+	for _, d := range testData {
+		wallet.Reset()
+		wallet.InitStorage(d.Storage)
+
+		wlt, err := wallet.NewBrainWallet("spo", "l1", "seed1", passwd)
+		assert.NoError(t, err, d.Name)
+		name := wlt.GetID() + "." + wallet.Ext
+		cnt, err := d.Storage.Read(name)
+		assert.NoError(t, err, d.Name)
+		old := strings.Replace(string(cnt), `"version": "`+wallet.WalletVersion+`"`, `"version": "0.1"`, 1)
+		assert.NoError(t, d.Storage.Write(name, []byte(old)), d.Name)
+
+		wallet.Reset()
+		_, err = wallet.LoadWallet()
+		assert.NoError(t, err, d.Name)
+		assert.True(t, d.Storage.Exist(name+".0.1.bak"), d.Name)
+
+		// the backups of migration are removed with the wallet.
+		assert.NoError(t, wallet.Remove(wlt.GetID()), d.Name)
+		names, err := d.Storage.List()
+		assert.NoError(t, err, d.Name)
+		for _, n := range names {
+			assert.False(t, strings.HasPrefix(n, name), d.Name+" "+n)
+		}
+		assert.Error(t, d.Storage.Restore(name), d.Name)
+		assert.Error(t, d.Storage.Restore(name+".0.1.bak"), d.Name)
+	}
+	wallet.InitDir(wltDir)
+}
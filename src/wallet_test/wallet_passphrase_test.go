@@ -0,0 +1,92 @@
+package wallet_test
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/MDLlife/wallet-api/src/coin/spo"
+	"github.com/MDLlife/wallet-api/src/wallet"
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPassphrase(t *testing.T) {
+	wltDir, teardown, err := setup(t)
+	assert.Nil(t, err)
+	defer teardown()
+	wallet.Reset()
+
+	passwd := "12345678"
+	mnemonic := strings.Repeat("abandon ", 11) + "about"
+	// bip39 test vector of the mnemonic with passphrase TREZOR.
+	root, err := hex.DecodeString("c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04")
+	assert.NoError(t, err)
+	_, seckeys := cipher.GenerateDeterministicKeyPairsSeed(root, 3)
+	expect := []string{}
+	for _, sec := range seckeys {
+		expect = append(expect, cipher.AddressFromSecKey(sec).String())
+	}
+
+	wlt, err := wallet.NewWithPassphrase("spo", "l1", mnemonic, "TREZOR", passwd)
+	assert.NoError(t, err)
+	id := wlt.GetID()
+	assert.Equal(t, "spo_"+expect[0][:16], id)
+	assert.Equal(t, expect[:1], wlt.GetAddresses())
+
+	// each passphrase opens another wallet of the mnemonic.
+	plain, err := wallet.New("spo", "l2", mnemonic, passwd)
+	assert.NoError(t, err)
+	assert.Equal(t, wallet.MakeWltID("spo", mnemonic), plain.GetID())
+	other, err := wallet.NewWithPassphrase("spo", "l3", mnemonic, "TREZOR2", passwd)
+	assert.NoError(t, err)
+	assert.NotEqual(t, id, other.GetID())
+	assert.NotEqual(t, plain.GetID(), other.GetID())
+	_, err = wallet.NewWithPassphrase("spo", "l4", "seed1", "TREZOR", passwd)
+	assert.Error(t, err)
+
+	// the passphrase is kept encrypted, addresses continue after reload.
+	cnt, err := ioutil.ReadFile(filepath.Join(wltDir, id+"."+wallet.Ext))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(cnt), "TREZOR")
+	wallet.Reset()
+	_, err = wallet.LoadWallet()
+	assert.NoError(t, err)
+	_, err = wallet.NewAddresses(id, 2, passwd)
+	assert.NoError(t, err)
+	addrs, err := wallet.GetAddresses(id)
+	assert.NoError(t, err)
+	assert.Equal(t, expect, addrs)
+	seed, err := wallet.GetSeed(id, passwd)
+	assert.NoError(t, err)
+	assert.Equal(t, mnemonic, seed)
+	assert.NoError(t, wallet.ChangePassword(id, passwd, "87654321"))
+	_, err = wallet.NewAddresses(id, 1, "87654321")
+	assert.NoError(t, err)
+}
+
+func TestPassphraseNFKD(t *testing.T) {
+	_, teardown, err := setup(t)
+	assert.Nil(t, err)
+	defer teardown()
+	wallet.Reset()
+
+	passwd := "12345678"
+	mnemonic := strings.Repeat("abandon ", 11) + "about"
+	// pbkdf2 of the mnemonic with the NFKD form of the passphrase, "Gru\u0308\u00dfe TREZOR".
+	root, err := hex.DecodeString("f979d11995be4115d9de602578b1a08e1da96288519275bc38dfeac6106c65d0ccb5038cc39582f54521a2a0fa83042da05e6fb5778c34cbde71fca021d2a3ba")
+	assert.NoError(t, err)
+	_, seckeys := cipher.GenerateDeterministicKeyPairsSeed(root, 1)
+	expect := cipher.AddressFromSecKey(seckeys[0]).String()
+
+	// the composed and the fullwidth forms are the same passphrase.
+	wlt, err := wallet.NewWithPassphrase("spo", "l1", mnemonic, "Gr\u00fc\u00dfe \uff34\uff32\uff25\uff3a\uff2f\uff32", passwd)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{expect}, wlt.GetAddresses())
+	assert.Equal(t, "spo_"+expect[:16], wlt.GetID())
+	assert.True(t, wallet.IsExist(wlt.GetID()))
+	_, err = wallet.NewWithPassphrase("spo", "l2", mnemonic, "Gru\u0308\u00dfe TREZOR", passwd)
+	assert.EqualError(t, err, wlt.GetID()+" already exist")
+}
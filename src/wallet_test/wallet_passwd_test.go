@@ -0,0 +1,257 @@
+package wallet_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MDLlife/wallet-api/src/coin/skycoin"
+	_ "github.com/MDLlife/wallet-api/src/coin/spo"
+	"github.com/MDLlife/wallet-api/src/util/encrypt"
+	"github.com/MDLlife/wallet-api/src/wallet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangePassword(t *testing.T) {
+	_, teardown, err := setup(t)
+	assert.Nil(t, err)
+	defer teardown()
+	wallet.Reset()
+
+	oldPasswd := "12345678"
+	newPasswd := "87654321"
+	wlt, err := wallet.NewBrainWallet("spo", "l1", "seed1", oldPasswd)
+	assert.NoError(t, err)
+
+	err = wallet.ChangePassword(wlt.GetID(), newPasswd, newPasswd)
+	assert.EqualError(t, err, wlt.GetID()+" wallet password incorrect")
+
+	err = wallet.ChangePassword(wlt.GetID(), oldPasswd, newPasswd)
+	assert.NoError(t, err)
+
+	// reload from disk
+	wallet.Reset()
+	_, err = wallet.LoadWallet()
+	assert.NoError(t, err)
+	assert.Error(t, wallet.VerifyPassword(wlt.GetID(), oldPasswd))
+	assert.NoError(t, wallet.VerifyPassword(wlt.GetID(), newPasswd))
+	seed, err := wallet.GetSeed(wlt.GetID(), newPasswd)
+	assert.NoError(t, err)
+	assert.Equal(t, "seed1", seed)
+
+	_, err = wallet.NewAddresses(wlt.GetID(), 1, newPasswd)
+	assert.NoError(t, err)
+}
+
+func TestChangeAllPasswordsRollback(t *testing.T) {
+	wltDir, teardown, err := setup(t)
+	assert.Nil(t, err)
+	defer teardown()
+	wallet.Reset()
+
+	oldPasswd := "12345678"
+	newPasswd := "87654321"
+	ids := []string{}
+	for _, d := range []struct{ Type, Seed string }{
+		{"skycoin", "seed1"},
+		{"spo", "seed2"},
+		{"spo", "seed3"},
+	} {
+		wlt, err := wallet.NewBrainWallet(d.Type, "lable", d.Seed, oldPasswd)
+		assert.NoError(t, err)
+		ids = append(ids, wlt.GetID())
+	}
+
+	// the tmp file of the last wallet can not be created.
+	tmpPath := filepath.Join(wltDir, ids[2]+"."+wallet.Ext+".tmp")
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpPath, "blocker"), 0777))
+
+	err = wallet.ChangeAllPasswords(oldPasswd, newPasswd)
+	assert.Error(t, err)
+
+	for _, id := range ids {
+		assert.NoError(t, wallet.VerifyPassword(id, oldPasswd))
+	}
+
+	// files on disk are rolled back too
+	wallet.Reset()
+	_, err = wallet.LoadWallet()
+	assert.NoError(t, err)
+	for _, id := range ids {
+		assert.NoError(t, wallet.VerifyPassword(id, oldPasswd))
+		assert.Error(t, wallet.VerifyPassword(id, newPasswd))
+	}
+
+	assert.NoError(t, os.RemoveAll(tmpPath))
+	assert.NoError(t, wallet.ChangeAllPasswords(oldPasswd, newPasswd))
+
+	wallet.Reset()
+	_, err = wallet.LoadWallet()
+	assert.NoError(t, err)
+	for _, id := range ids {
+		assert.NoError(t, wallet.VerifyPassword(id, newPasswd))
+	}
+}
+
+func TestRewrapCipher(t *testing.T) {
+	wltDir, teardown, err := setup(t)
+	assert.Nil(t, err)
+	defer teardown()
+	wallet.Reset()
+
+	passwd := "12345678"
+	assert.NoError(t, wallet.SetEncryptOptions(encrypt.Options{Cipher: encrypt.CipherScryptChacha20poly1305, N: 1 << 10}))
+	defer wallet.SetEncryptOptions(encrypt.DefaultOptions)
+
+	wlt, err := wallet.NewBrainWallet("spo", "l1", "seed1", passwd)
+	assert.NoError(t, err)
+	path := filepath.Join(wltDir, wlt.GetID()+"."+wallet.Ext)
+	cnt, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(cnt), encrypt.CipherScryptChacha20poly1305)
+
+	argon2id := encrypt.Options{Cipher: encrypt.CipherArgon2idChacha20poly1305, Time: 1, Memory: 1024, Threads: 1}
+	assert.NoError(t, wallet.SetRewrapOptions(&argon2id))
+	defer wallet.SetRewrapOptions(nil)
+
+	// wrong password does not re-wrap
+	_, err = wallet.GetSeed(wlt.GetID(), "87654321")
+	assert.Error(t, err)
+	cnt, err = ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(cnt), encrypt.CipherArgon2idChacha20poly1305)
+
+	seed, err := wallet.GetSeed(wlt.GetID(), passwd)
+	assert.NoError(t, err)
+	assert.Equal(t, "seed1", seed)
+
+	wallet.Reset()
+	_, err = wallet.LoadWallet()
+	assert.NoError(t, err)
+	cnt, err = ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(cnt), encrypt.CipherArgon2idChacha20poly1305)
+	seed, err = wallet.GetSeed(wlt.GetID(), passwd)
+	assert.NoError(t, err)
+	assert.Equal(t, "seed1", seed)
+
+	// select the cipher of specific wallet
+	assert.NoError(t, wallet.SetRewrapOptions(nil))
+	assert.NoError(t, wallet.SetCipher(wlt.GetID(), passwd, encrypt.Options{Cipher: encrypt.CipherScryptChacha20poly1305, N: 1 << 10}))
+	cnt, err = ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(cnt), encrypt.CipherScryptChacha20poly1305)
+	_, err = wallet.NewAddresses(wlt.GetID(), 1, passwd)
+	assert.NoError(t, err)
+}
+
+func TestOpenWithoutPassword(t *testing.T) {
+	wltDir, teardown, err := setup(t)
+	assert.Nil(t, err)
+	defer teardown()
+	wallet.Reset()
+
+	passwd := "12345678"
+	wlt, err := wallet.NewBrainWallet("spo", "l1", "seed1", passwd)
+	assert.NoError(t, err)
+	_, err = wallet.NewAddresses(wlt.GetID(), 2, passwd)
+	assert.NoError(t, err)
+	addrs, err := wallet.GetAddresses(wlt.GetID())
+	assert.NoError(t, err)
+
+	// public fields are enough for listing addresses.
+	wallet.Reset()
+	_, err = wallet.LoadWallet()
+	assert.NoError(t, err)
+	loaded, err := wallet.GetAddresses(wlt.GetID())
+	assert.NoError(t, err)
+	assert.Equal(t, addrs, loaded)
+	ok, err := wallet.IsContain(wlt.GetID(), addrs)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// secrets need the password.
+	_, err = wallet.NewAddresses(wlt.GetID(), 1, "87654321")
+	assert.EqualError(t, err, "wallet password incorrect")
+	_, _, err = wallet.GetKeypair(wlt.GetID(), addrs[0], "87654321")
+	assert.Error(t, err)
+	_, _, err = wallet.GetKeypair(wlt.GetID(), addrs[0], passwd)
+	assert.NoError(t, err)
+
+	// wallet which failed to be saved is not kept in memory.
+	tmpPath := filepath.Join(wltDir, wallet.MakeWltID("spo", "seed2")+"."+wallet.Ext+".tmp")
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpPath, "blocker"), 0777))
+	_, err = wallet.NewBrainWallet("spo", "l2", "seed2", passwd)
+	assert.Error(t, err)
+	assert.False(t, wallet.IsExist(wallet.MakeWltID("spo", "seed2")))
+}
+
+func TestChangePasswordRemovesOldCopies(t *testing.T) {
+	wltDir, teardown, err := setup(t)
+	assert.Nil(t, err)
+	defer teardown()
+
+	boltStorage, err := wallet.NewBoltStorage(filepath.Join(wltDir, "wallets.db"))
+	assert.NoError(t, err)
+
+	testData := []struct {
+		Name    string
+		Storage wallet.Storage
+	}{
+		{"file", wallet.NewFileStorage(wltDir)},
+		{"memory", wallet.NewMemoryStorage()},
+		{"bolt", boltStorage},
+	}
+
+	oldPasswd := "12345678"
+	newPasswd := "87654321"
+	for _, d := range testData {
+		wallet.Reset()
+		wallet.InitStorage(d.Storage)
+
+		wlt, err := wallet.NewBrainWallet("spo", "l1", "seed1", oldPasswd)
+		assert.NoError(t, err, d.Name)
+		// the file encrypted by the old password is kept as backup.
+		_, err = wallet.NewAddresses(wlt.GetID(), 1, oldPasswd)
+		assert.NoError(t, err, d.Name)
+
+		assert.NoError(t, wallet.ChangePassword(wlt.GetID(), oldPasswd, newPasswd), d.Name)
+		name := wlt.GetID() + "." + wallet.Ext
+		assert.Error(t, d.Storage.Restore(name), d.Name)
+
+		wallet.Reset()
+		report, err := wallet.LoadWallet()
+		assert.NoError(t, err, d.Name)
+		assert.Empty(t, report.Recovered, d.Name)
+		assert.Error(t, wallet.VerifyPassword(wlt.GetID(), oldPasswd), d.Name)
+		assert.NoError(t, wallet.VerifyPassword(wlt.GetID(), newPasswd), d.Name)
+		assert.NoError(t, wallet.Remove(wlt.GetID()), d.Name)
+	}
+	wallet.InitDir(wltDir)
+
+	// the old password opens none of the files left on disk.
+	wallet.Reset()
+	wlt, err := wallet.NewBrainWallet("spo", "l1", "seed1", oldPasswd)
+	assert.NoError(t, err)
+	_, err = wallet.NewAddresses(wlt.GetID(), 1, oldPasswd)
+	assert.NoError(t, err)
+	assert.NoError(t, wallet.ChangePassword(wlt.GetID(), oldPasswd, newPasswd))
+	fileInfos, err := ioutil.ReadDir(wltDir)
+	assert.NoError(t, err)
+	opened := 0
+	for _, fileInfo := range fileInfos {
+		d, err := ioutil.ReadFile(filepath.Join(wltDir, fileInfo.Name()))
+		assert.NoError(t, err)
+		w := &skycoin.Wallet{}
+		if w.Load(bytes.NewReader(d)) != nil || w.Secrets == "" {
+			continue
+		}
+		assert.Error(t, w.IsPasswordCorrect([]byte(oldPasswd)), fileInfo.Name())
+		if w.IsPasswordCorrect([]byte(newPasswd)) == nil {
+			opened++
+		}
+	}
+	assert.Equal(t, 1, opened)
+}
@@ -0,0 +1,107 @@
+package wallet_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	_ "github.com/MDLlife/wallet-api/src/coin/spo"
+	"github.com/MDLlife/wallet-api/src/wallet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestore(t *testing.T) {
+	_, teardown, err := setup(t)
+	assert.Nil(t, err)
+	defer teardown()
+	wallet.Reset()
+
+	passwd := "12345678"
+	mnemonic := strings.Repeat("abandon ", 11) + "about"
+
+	// derive the addresses of the mnemonic.
+	wlt, err := wallet.New("spo", "l1", mnemonic, passwd)
+	assert.NoError(t, err)
+	_, err = wallet.NewAddresses(wlt.GetID(), 59, passwd)
+	assert.NoError(t, err)
+	derived, err := wallet.GetAddresses(wlt.GetID())
+	assert.NoError(t, err)
+	assert.NoError(t, wallet.Remove(wlt.GetID()))
+
+	usedBy := func(idxs ...int) (wallet.UsageChecker, *int) {
+		used := make(map[string]bool)
+		for _, i := range idxs {
+			used[derived[i]] = true
+		}
+		var checked int
+		return func(addrs []string) (map[string]bool, error) {
+			checked += len(addrs)
+			m := make(map[string]bool)
+			for _, a := range addrs {
+				m[a] = used[a]
+			}
+			return m, nil
+		}, &checked
+	}
+
+	testData := []struct {
+		Used    []int
+		Gap     int
+		Num     int
+		Checked int
+	}{
+		{nil, 0, 1, 20},
+		{[]int{0, 5}, 0, 6, 40},
+		{[]int{0, 25}, 0, 26, 60},
+		{[]int{0, 25}, 10, 1, 20},
+		{[]int{3, 12, 21}, 10, 22, 40},
+	}
+	for _, d := range testData {
+		used, checked := usedBy(d.Used...)
+		wlt, err := wallet.Restore("spo", "l1", mnemonic, "", passwd, d.Gap, used)
+		assert.NoError(t, err)
+		assert.Equal(t, wallet.MakeWltID("spo", mnemonic), wlt.GetID())
+		addrs, err := wallet.GetAddresses(wlt.GetID())
+		assert.NoError(t, err)
+		assert.Equal(t, derived[:d.Num], addrs)
+		assert.Equal(t, d.Checked, *checked)
+		isUsed := func(i int) bool {
+			for _, u := range d.Used {
+				if u == i {
+					return true
+				}
+			}
+			return false
+		}
+		for i, e := range wlt.GetAddressEntries() {
+			assert.Equal(t, isUsed(i), e.Used)
+		}
+
+		// the used addresses and their flags are persisted.
+		wallet.Reset()
+		_, err = wallet.LoadWallet()
+		assert.NoError(t, err)
+		addrs, err = wallet.GetAddresses(wlt.GetID())
+		assert.NoError(t, err)
+		assert.Len(t, addrs, d.Num)
+		entries, err := wallet.GetAddressEntries(wlt.GetID())
+		assert.NoError(t, err)
+		for i, e := range entries {
+			assert.Equal(t, isUsed(i), e.Used)
+		}
+		assert.NoError(t, wallet.Remove(wlt.GetID()))
+	}
+
+	used, _ := usedBy()
+	_, err = wallet.Restore("spo", "l1", "", "", passwd, 0, used)
+	assert.EqualError(t, err, "seed is required to restore wallet")
+	_, err = wallet.Restore("spo", "l1", "seed1", "", passwd, 0, used)
+	assert.EqualError(t, err, "invalid mnemonic, 1 words, must be 12, 15, 18, 21 or 24 words")
+	_, err = wallet.Restore("btc", "l1", mnemonic, "", passwd, 0, used)
+	assert.EqualError(t, err, "btc wallet not regestered")
+	_, err = wallet.Restore("spo", "l1", mnemonic, "", passwd, 0, func([]string) (map[string]bool, error) {
+		return nil, errors.New("node unreachable")
+	})
+	assert.EqualError(t, err, "node unreachable")
+	assert.False(t, wallet.IsExist(wallet.MakeWltID("spo", mnemonic)))
+}
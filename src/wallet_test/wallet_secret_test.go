@@ -0,0 +1,106 @@
+package wallet_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/MDLlife/wallet-api/src/coin/skycoin"
+	"github.com/MDLlife/wallet-api/src/util/secure"
+	"github.com/MDLlife/wallet-api/src/wallet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWipeSecrets(t *testing.T) {
+	passwd := []byte("12345678")
+	wlt := &skycoin.Wallet{Wallet: wallet.Wallet{Type: "spo"}}
+	wlt.SetConstant()
+	wlt.SetID(wallet.MakeWltID("spo", "seed1"))
+	wlt.SetSeed("seed1")
+	initSeed := wlt.InitSeed
+	_, err := wlt.NewAddresses(2)
+	assert.NoError(t, err)
+	seed := wlt.Seed
+	addrs := wlt.GetAddresses()
+	assert.Len(t, addrs, 2)
+
+	// secrets are wiped after save.
+	var buf bytes.Buffer
+	assert.NoError(t, wlt.Save(&buf, passwd))
+	assert.True(t, secure.IsWiped(initSeed))
+	assert.True(t, secure.IsWiped(seed))
+	assert.Nil(t, wlt.Seed)
+	assert.Nil(t, wlt.InitSeed)
+	assert.NotContains(t, buf.String(), "seed1")
+
+	loaded := &skycoin.Wallet{}
+	assert.NoError(t, loaded.Load(bytes.NewReader(buf.Bytes())))
+	assert.Nil(t, loaded.Seed)
+
+	// secrets decrypted before are wiped by next decryption, and erased after GetKeypair.
+	assert.NoError(t, loaded.Decryption(passwd))
+	seed = loaded.Seed
+	assert.False(t, secure.IsWiped(seed))
+	_, sec, err := loaded.GetKeypair(addrs[1], passwd)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, sec)
+	assert.True(t, secure.IsWiped(seed))
+	assert.Nil(t, loaded.Seed)
+	assert.NotContains(t, buf.String(), sec)
+
+	// the seckey returned is a copy, the wallet is erased.
+	key, err := loaded.GetSeckey(addrs[1], passwd)
+	assert.NoError(t, err)
+	assert.Len(t, key, 32)
+	assert.Nil(t, loaded.Seed)
+	secure.Wipe(key)
+	_, sec2, err := loaded.GetKeypair(addrs[1], passwd)
+	assert.NoError(t, err)
+	assert.Equal(t, sec, sec2)
+
+	_, err = loaded.GetSeckey(addrs[1], []byte("87654321"))
+	assert.EqualError(t, err, "wallet password incorrect")
+	s, err := loaded.GetSeed(passwd)
+	assert.NoError(t, err)
+	assert.Equal(t, "seed1", s)
+	assert.Nil(t, loaded.InitSeed)
+	sd, err := loaded.GetSeedBytes(passwd)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("seed1"), sd)
+	assert.Nil(t, loaded.InitSeed)
+	secure.Wipe(sd)
+	assert.True(t, secure.IsWiped(sd))
+
+	// the unlocked wallet keeps the derived key instead of the password, it's saved
+	// without password after the buffer of the password is wiped.
+	p := secure.Copy(passwd)
+	assert.NoError(t, loaded.Unlock(p))
+	secure.Wipe(p)
+	buf.Reset()
+	assert.NoError(t, loaded.Save(&buf, nil))
+	assert.NotContains(t, buf.String(), "seed1")
+	assert.Equal(t, []byte("seed1"), loaded.InitSeed)
+	loaded.Lock()
+	assert.Nil(t, loaded.InitSeed)
+	assert.EqualError(t, loaded.Save(&buf, nil), "empty seed")
+	reloaded := &skycoin.Wallet{}
+	assert.NoError(t, reloaded.Load(bytes.NewReader(buf.Bytes())))
+	s, err = reloaded.GetSeed(passwd)
+	assert.NoError(t, err)
+	assert.Equal(t, "seed1", s)
+
+	// seeds are quoted by hand, special characters survive the round trip.
+	for _, sd := range []string{"se\"ed\\1", "seed\n\t\x01", "种子 seed ✓"} {
+		w := &skycoin.Wallet{Wallet: wallet.Wallet{Type: "spo"}}
+		w.SetConstant()
+		w.SetID(wallet.MakeWltID("spo", sd))
+		w.SetSeed(sd)
+		_, err := w.NewAddresses(1)
+		assert.NoError(t, err)
+		buf.Reset()
+		assert.NoError(t, w.Save(&buf, passwd))
+		assert.NoError(t, w.Load(bytes.NewReader(buf.Bytes())))
+		s, err := w.GetSeed(passwd)
+		assert.NoError(t, err)
+		assert.Equal(t, sd, s)
+	}
+}
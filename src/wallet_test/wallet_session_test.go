@@ -0,0 +1,146 @@
+package wallet_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/MDLlife/wallet-api/src/coin/spo"
+	"github.com/MDLlife/wallet-api/src/util/encrypt"
+	"github.com/MDLlife/wallet-api/src/wallet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnlock(t *testing.T) {
+	wltDir, teardown, err := setup(t)
+	assert.Nil(t, err)
+	defer teardown()
+	wallet.Reset()
+
+	passwd := "12345678"
+	wlt, err := wallet.NewBrainWallet("spo", "l1", "seed1", passwd)
+	assert.NoError(t, err)
+	id := wlt.GetID()
+	addrs, err := wallet.GetAddresses(id)
+	assert.NoError(t, err)
+
+	assert.EqualError(t, wallet.Unlock(id, "87654321", time.Minute), "wallet password incorrect")
+	assert.EqualError(t, wallet.Unlock(id, passwd, 0), "invalid unlock ttl")
+	assert.False(t, wallet.IsUnlocked(id))
+	_, _, err = wallet.GetKeypair(id, addrs[0], "")
+	assert.Error(t, err)
+
+	assert.NoError(t, wallet.Unlock(id, passwd, time.Minute))
+	assert.True(t, wallet.IsUnlocked(id))
+
+	// empty or the same password is accepted by the unlocked wallet.
+	pub, sec, err := wallet.GetKeypair(id, addrs[0], "")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, pub)
+	assert.NotEmpty(t, sec)
+	_, s, err := wallet.GetKeypair(id, addrs[0], passwd)
+	assert.NoError(t, err)
+	assert.Equal(t, sec, s)
+	_, _, err = wallet.GetKeypair(id, addrs[0], "87654321")
+	assert.EqualError(t, err, "wallet password incorrect")
+	// unlock again by the session extends it, the key derived on unlock is kept.
+	assert.NoError(t, wallet.Unlock(id, "", time.Minute))
+	seed, err := wallet.GetSeed(id, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "seed1", seed)
+	assert.NoError(t, wallet.VerifyPassword(id, ""))
+
+	// new addresses are saved by the key of the session, the cipher needs the password.
+	assert.EqualError(t, wallet.SetCipher(id, "", encrypt.DefaultOptions), "password is required to change the cipher")
+	_, err = wallet.NewAddresses(id, 1, "")
+	assert.NoError(t, err)
+	_, err = wallet.NewAddresses(id, 1, "")
+	assert.NoError(t, err)
+	addrs, err = wallet.GetAddresses(id)
+	assert.NoError(t, err)
+	assert.Len(t, addrs, 3)
+	// the decrypted secrets of the unlocked wallet are not saved in plaintext.
+	cnt, err := ioutil.ReadFile(filepath.Join(wltDir, id+"."+wallet.Ext))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(cnt), "seed1")
+	_, s, err = wallet.GetKeypair(id, addrs[2], "")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, s)
+
+	assert.NoError(t, wallet.Lock(id))
+	assert.False(t, wallet.IsUnlocked(id))
+	_, err = wallet.GetSeed(id, "")
+	assert.Error(t, err)
+	_, s2, err := wallet.GetKeypair(id, addrs[2], passwd)
+	assert.NoError(t, err)
+	assert.Equal(t, s, s2)
+
+	// the session expires.
+	assert.NoError(t, wallet.Unlock(id, passwd, 50*time.Millisecond))
+	assert.True(t, wallet.IsUnlocked(id))
+	time.Sleep(200 * time.Millisecond)
+	assert.False(t, wallet.IsUnlocked(id))
+	_, err = wallet.GetSeed(id, "")
+	assert.Error(t, err)
+
+	// changing password ends the session.
+	newPasswd := "87654321"
+	assert.NoError(t, wallet.Unlock(id, passwd, time.Minute))
+	assert.NoError(t, wallet.ChangePassword(id, passwd, newPasswd))
+	assert.False(t, wallet.IsUnlocked(id))
+	seed, err = wallet.GetSeed(id, newPasswd)
+	assert.NoError(t, err)
+	assert.Equal(t, "seed1", seed)
+
+	// reload locks the wallets.
+	assert.NoError(t, wallet.Unlock(id, newPasswd, time.Minute))
+	wallet.Reset()
+	_, err = wallet.LoadWallet()
+	assert.NoError(t, err)
+	assert.False(t, wallet.IsUnlocked(id))
+	assert.Error(t, wallet.Lock("spo_notexist"))
+}
+
+func TestGetSeckeys(t *testing.T) {
+	_, teardown, err := setup(t)
+	assert.Nil(t, err)
+	defer teardown()
+	wallet.Reset()
+
+	passwd := "12345678"
+	wlt, err := wallet.NewBrainWallet("spo", "l1", "seed1", passwd)
+	assert.NoError(t, err)
+	id := wlt.GetID()
+	_, err = wallet.NewAddresses(id, 1, passwd)
+	assert.NoError(t, err)
+	addrs, err := wallet.GetAddresses(id)
+	assert.NoError(t, err)
+
+	_, err = wallet.GetSeckeys(id, addrs, "87654321")
+	assert.EqualError(t, err, "wallet password incorrect")
+	_, err = wallet.GetSeckeys("spo_notexist", addrs, passwd)
+	assert.Error(t, err)
+	_, err = wallet.GetSeckeys(id, []string{addrs[0], "notexist"}, passwd)
+	assert.EqualError(t, err, "notexist addr does not exist in wallet")
+
+	// the keys of all addresses are taken by one decryption, the wallet stays locked.
+	secs, err := wallet.GetSeckeys(id, addrs, passwd)
+	assert.NoError(t, err)
+	assert.Len(t, secs, 2)
+	assert.False(t, wallet.IsUnlocked(id))
+	for i, addr := range addrs {
+		sec, err := wallet.GetSeckey(id, addr, passwd)
+		assert.NoError(t, err)
+		assert.Equal(t, sec, secs[i])
+	}
+	_, err = wallet.GetSeed(id, "")
+	assert.Error(t, err)
+
+	// the unlocked wallet accepts empty password.
+	assert.NoError(t, wallet.Unlock(id, passwd, time.Minute))
+	s, err := wallet.GetSeckeys(id, addrs, "")
+	assert.NoError(t, err)
+	assert.Equal(t, secs, s)
+	assert.NoError(t, wallet.Lock(id))
+}
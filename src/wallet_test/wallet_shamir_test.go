@@ -0,0 +1,165 @@
+package wallet_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/MDLlife/wallet-api/src/coin/spo"
+	"github.com/MDLlife/wallet-api/src/wallet"
+	"github.com/stretchr/testify/assert"
+)
+
+// 2 of 3 shares of "seed1".
+var seedShares = []string{
+	"011234020010ef8c313013cc465ca368978d7269968c6c17bcc1",
+	"011234020110598ddbc670f54f56a8649a837d79879e1602a277",
+	"011234020210988efec7d5be5448b5708d916c49b4a8f205265b",
+}
+
+// 3 of 5 shares of the mnemonic.
+var mnemonicShares = []string{
+	"01beef03005eb0d51c58c8b9c1f458fadf16c7d375630ef51da4df81915893b05c0fa4ed8bc68041cd78545764666c907d98e0332a9736f2153ffa8297e2c96d2331f1609084a69c7867261e5b739f491f19abffe4fe7b003aed48c5263359fa1de9d1e10fa0f449",
+	"01beef03015ef4588844fcd2a4ca5023a6815302da137b66440e4e3f82d9bc29e1f7e2cd7eff01c3e8897b556174876142250feede6c2c22becb273e84dd88bfe1331b7be5103e73cd9101c8a350385e6c1908f2c31638ddfeb781919579b5ab42d21c236060e6d8",
+	"01beef03025ee5cd178843f4d85ff55db080468e48a49dc0cd34868d50965649eb8f2c15b036c002cd250f0c278231b25c5a2e3d07f683ac087bf8cf9ee130bf4d3ac00441b0f3fdc7da9385b60ec69da920306e5d05b5dce39121f9bc3e424aa2e3fa612f3cde53",
+	"01beef03035ea1408394779fbd61fd84c917d25fe7d4e853949e1733431779d056776a35450f4180e8d4200e2290da4363e7c1e0f30d997ca38f25738dde716d8f382a1f34246b12722cb4534e2d618ada2093637aedf60127cbe8ad0f74ae1bfdd837a32366b2c2",
+	"01beef03045e4381ea6e647992c8db97439b20d4f46accbf35cef62eb9ae58345ca3bfd0f8037facfe64f686dd98a09558bd4ba5255375f7745d44a0390bd77d161b46fbc5f0eff80835968382651d3f338fb3dc7cd3c74ca01837dbb87b22c97c16198611d6cb11",
+}
+
+func TestCombineShares(t *testing.T) {
+	mnemonic := strings.Repeat("abandon ", 11) + "about"
+	testData := []struct {
+		Name   string
+		Shares []string
+		Secret string
+		Err    string
+	}{
+		{"share 1 2", []string{seedShares[0], seedShares[1]}, "seed1", ""},
+		{"share 3 1", []string{seedShares[2], seedShares[0]}, "seed1", ""},
+		{"all shares", seedShares, "seed1", ""},
+		{"share 1 3 5", []string{mnemonicShares[0], mnemonicShares[2], mnemonicShares[4]}, mnemonic, ""},
+		{"share 4 2 5 1", []string{mnemonicShares[3], mnemonicShares[1], mnemonicShares[4], mnemonicShares[0]}, mnemonic, ""},
+		{"no share", nil, "", "no share"},
+		{"below threshold", []string{seedShares[1]}, "", "need 2 shares, got 1"},
+		{"below threshold of 3", []string{mnemonicShares[0], mnemonicShares[4]}, "", "need 3 shares, got 2"},
+		{"duplicate share", []string{seedShares[1], seedShares[1]}, "", "duplicate share 2"},
+		{"duplicate share of 3", []string{mnemonicShares[0], mnemonicShares[2], mnemonicShares[0]}, "", "duplicate share 3"},
+		{"mistyped share", []string{seedShares[0], strings.Replace(seedShares[1], "598d", "598e", 1)}, "", "invalid share 2: checksum failed"},
+		{"truncated share", []string{seedShares[0], seedShares[1][:40]}, "", "invalid share 2: invalid length"},
+		{"not hex", []string{"seed1", seedShares[1]}, "", "invalid share 1: not hex"},
+		{"share of other secret", []string{seedShares[0], mnemonicShares[1], mnemonicShares[2]}, "", "share 2 doesn't belong to the same secret"},
+		// the value is changed and the checksum is recomputed.
+		{"wrong share", []string{seedShares[0], "011234020110598ddac670f54f56a8649a837d79879e338279a8"}, "", "invalid shares, the digest of secret does not match"},
+	}
+
+	for _, d := range testData {
+		secret, err := wallet.CombineShares(d.Shares)
+		if d.Err != "" {
+			assert.EqualError(t, err, d.Err, d.Name)
+			continue
+		}
+		assert.NoError(t, err, d.Name)
+		assert.Equal(t, d.Secret, string(secret), d.Name)
+	}
+}
+
+func TestSplitSecret(t *testing.T) {
+	for _, secret := range []string{"s", "seed1", strings.Repeat("abandon ", 23) + "art"} {
+		for _, c := range []struct{ Threshold, Count int }{{1, 1}, {1, 3}, {2, 2}, {3, 5}, {16, 16}} {
+			shares, err := wallet.SplitSecret([]byte(secret), c.Threshold, c.Count)
+			assert.NoError(t, err)
+			assert.Len(t, shares, c.Count)
+			s, err := wallet.CombineShares(shares[c.Count-c.Threshold:])
+			assert.NoError(t, err)
+			assert.Equal(t, secret, string(s))
+			if c.Threshold > 1 {
+				// the shares reveal nothing of the secret.
+				for _, share := range shares {
+					assert.NotContains(t, share, "7365656431")
+				}
+				_, err = wallet.CombineShares(shares[1:c.Threshold])
+				assert.Error(t, err)
+			}
+		}
+	}
+
+	// another split has another identifier.
+	s1, err := wallet.SplitSecret([]byte("seed1"), 2, 2)
+	assert.NoError(t, err)
+	s2, err := wallet.SplitSecret([]byte("seed1"), 2, 2)
+	assert.NoError(t, err)
+	_, err = wallet.CombineShares([]string{s1[0], s2[1]})
+	assert.Error(t, err)
+
+	_, err = wallet.SplitSecret(nil, 2, 3)
+	assert.EqualError(t, err, "secret length must be 1 to 254 bytes")
+	_, err = wallet.SplitSecret([]byte("seed1"), 2, 17)
+	assert.EqualError(t, err, "share count must be 1 to 16")
+	_, err = wallet.SplitSecret([]byte("seed1"), 4, 3)
+	assert.EqualError(t, err, "threshold must be 1 to share count")
+	_, err = wallet.SplitSecret([]byte("seed1"), 0, 3)
+	assert.EqualError(t, err, "threshold must be 1 to share count")
+}
+
+func TestSplitSeed(t *testing.T) {
+	_, teardown, err := setup(t)
+	assert.Nil(t, err)
+	defer teardown()
+	wallet.Reset()
+
+	passwd := "12345678"
+	wlt, err := wallet.NewBrainWallet("spo", "l1", "seed1", passwd)
+	assert.NoError(t, err)
+
+	_, err = wallet.SplitSeed(wlt.GetID(), 2, 3)
+	assert.EqualError(t, err, "unlock the wallet to split the seed")
+	_, err = wallet.SplitSeed("spo_none", 2, 3)
+	assert.EqualError(t, err, "spo_none wallet does not exist")
+
+	assert.NoError(t, wallet.Unlock(wlt.GetID(), passwd, time.Minute))
+	_, err = wallet.SplitSeed(wlt.GetID(), 4, 3)
+	assert.EqualError(t, err, "threshold must be 1 to share count")
+	shares, err := wallet.SplitSeed(wlt.GetID(), 2, 3)
+	assert.NoError(t, err)
+	assert.Len(t, shares, 3)
+	assert.True(t, wallet.IsUnlocked(wlt.GetID()))
+
+	// the test vectors restore the same wallet as well.
+	for _, ss := range [][]string{shares, seedShares} {
+		assert.NoError(t, wallet.Remove(wlt.GetID()))
+		_, err = wallet.RestoreFromShares("spo", "l2", ss[:1], "", passwd)
+		assert.EqualError(t, err, "need 2 shares, got 1")
+		_, err = wallet.RestoreFromShares("spo", "l2", []string{ss[2], ss[2]}, "", passwd)
+		assert.EqualError(t, err, "duplicate share 2")
+		assert.False(t, wallet.IsExist(wlt.GetID()))
+
+		restored, err := wallet.RestoreFromShares("spo", "l2", []string{ss[2], ss[0]}, "", passwd)
+		assert.NoError(t, err)
+		assert.Equal(t, wlt.GetID(), restored.GetID())
+		assert.Equal(t, "l2", restored.GetLable())
+		seed, err := wallet.GetSeed(wlt.GetID(), passwd)
+		assert.NoError(t, err)
+		assert.Equal(t, "seed1", seed)
+	}
+
+	// the passphrase isn't in the shares.
+	mnemonic := strings.Repeat("abandon ", 11) + "about"
+	pwlt, err := wallet.NewWithPassphrase("spo", "p1", mnemonic, "pass", passwd)
+	assert.NoError(t, err)
+	assert.NoError(t, wallet.Unlock(pwlt.GetID(), passwd, time.Minute))
+	shares, err = wallet.SplitSeed(pwlt.GetID(), 3, 5)
+	assert.NoError(t, err)
+	assert.NoError(t, wallet.Remove(pwlt.GetID()))
+	other, err := wallet.RestoreFromShares("spo", "p1", shares[2:], "", passwd)
+	assert.NoError(t, err)
+	assert.NotEqual(t, pwlt.GetID(), other.GetID())
+	restored, err := wallet.RestoreFromShares("spo", "p1", shares[:3], "pass", passwd)
+	assert.NoError(t, err)
+	assert.Equal(t, pwlt.GetID(), restored.GetID())
+
+	cwlt, err := wallet.NewCollection("spo", "c1", passwd)
+	assert.NoError(t, err)
+	assert.NoError(t, wallet.Unlock(cwlt.GetID(), passwd, time.Minute))
+	_, err = wallet.SplitSeed(cwlt.GetID(), 2, 3)
+	assert.EqualError(t, err, "wallet has no seed")
+}
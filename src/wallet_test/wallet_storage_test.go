@@ -0,0 +1,216 @@
+package wallet_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/MDLlife/wallet-api/src/coin/skycoin"
+	_ "github.com/MDLlife/wallet-api/src/coin/spo"
+	"github.com/MDLlife/wallet-api/src/wallet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorage(t *testing.T) {
+	wltDir, teardown, err := setup(t)
+	assert.Nil(t, err)
+	defer teardown()
+
+	boltStorage, err := wallet.NewBoltStorage(filepath.Join(wltDir, "wallets.db"))
+	assert.NoError(t, err)
+
+	testData := []struct {
+		Name    string
+		Storage wallet.Storage
+	}{
+		{"file", wallet.NewFileStorage(wltDir)},
+		{"memory", wallet.NewMemoryStorage()},
+		{"bolt", boltStorage},
+	}
+
+	passwd := "12345678"
+	for _, d := range testData {
+		wallet.Reset()
+		wallet.InitStorage(d.Storage)
+
+		wlt, err := wallet.NewBrainWallet("spo", "l1", "seed1", passwd)
+		assert.NoError(t, err, d.Name)
+		_, err = wallet.NewAddresses(wlt.GetID(), 2, passwd)
+		assert.NoError(t, err, d.Name)
+		addrs, err := wallet.GetAddresses(wlt.GetID())
+		assert.NoError(t, err, d.Name)
+		assert.Len(t, addrs, 3, d.Name)
+
+		names, err := d.Storage.List()
+		assert.NoError(t, err, d.Name)
+		assert.Contains(t, names, wlt.GetID()+"."+wallet.Ext, d.Name)
+
+		// reload from storage
+		wallet.Reset()
+		_, err = wallet.LoadWallet()
+		assert.NoError(t, err, d.Name)
+		loaded, err := wallet.GetAddresses(wlt.GetID())
+		assert.NoError(t, err, d.Name)
+		assert.Equal(t, addrs, loaded, d.Name)
+		seed, err := wallet.GetSeed(wlt.GetID(), passwd)
+		assert.NoError(t, err, d.Name)
+		assert.Equal(t, "seed1", seed, d.Name)
+
+		// restore the backup of last write
+		name := wlt.GetID() + "." + wallet.Ext
+		assert.NoError(t, d.Storage.Restore(name), d.Name)
+		wallet.Reset()
+		_, err = wallet.LoadWallet()
+		assert.NoError(t, err, d.Name)
+		loaded, err = wallet.GetAddresses(wlt.GetID())
+		assert.NoError(t, err, d.Name)
+		assert.Len(t, loaded, 1, d.Name)
+
+		assert.NoError(t, d.Storage.Write(name, []byte("{}")), d.Name)
+		assert.NoError(t, wallet.Remove(wlt.GetID()), d.Name)
+		assert.False(t, d.Storage.Exist(name), d.Name)
+		// the backup is removed with the file.
+		assert.Error(t, d.Storage.Restore(name), d.Name)
+		assert.False(t, wallet.IsExist(wlt.GetID()), d.Name)
+	}
+	wallet.InitDir(wltDir)
+}
+
+func TestRecoverWalletFile(t *testing.T) {
+	wltDir, teardown, err := setup(t)
+	assert.Nil(t, err)
+	defer teardown()
+	wallet.Reset()
+
+	passwd := "12345678"
+	wlt, err := wallet.NewBrainWallet("spo", "l1", "seed1", passwd)
+	assert.NoError(t, err)
+	_, err = wallet.NewAddresses(wlt.GetID(), 1, passwd)
+	assert.NoError(t, err)
+
+	name := wlt.GetID() + "." + wallet.Ext
+	path := filepath.Join(wltDir, name)
+	now := time.Now()
+	assert.NoError(t, os.Chtimes(path+".bak", now.Add(-time.Minute), now.Add(-time.Minute)))
+
+	// crash after the old file renamed to .bak, before .tmp renamed to .wlt.
+	assert.NoError(t, os.Rename(path, path+".tmp"))
+	wallet.Reset()
+	report, err := wallet.LoadWallet()
+	assert.NoError(t, err)
+	assert.Equal(t, []wallet.Recovery{{Name: name, From: name + ".tmp"}}, report.Recovered)
+	addrs, err := wallet.GetAddresses(wlt.GetID())
+	assert.NoError(t, err)
+	assert.Len(t, addrs, 2)
+	_, err = os.Stat(path + ".tmp")
+	assert.True(t, os.IsNotExist(err))
+
+	// stale temp file is removed.
+	assert.NoError(t, ioutil.WriteFile(path+".tmp", []byte("{"), 0600))
+	wallet.Reset()
+	report, err = wallet.LoadWallet()
+	assert.NoError(t, err)
+	assert.Empty(t, report.Recovered)
+	_, err = os.Stat(path + ".tmp")
+	assert.True(t, os.IsNotExist(err))
+
+	// newer temp file which can't be loaded doesn't replace the valid file.
+	cnt, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	corruptData := []struct {
+		Name   string
+		Modify func(fields map[string]interface{})
+	}{
+		{"mac", func(fields map[string]interface{}) { fields["mac"] = "not hex" }},
+		{"scrypt r", func(fields map[string]interface{}) { fields["crypto"].(map[string]interface{})["r"] = 1 << 20 }},
+		{"key length", func(fields map[string]interface{}) { fields["crypto"].(map[string]interface{})["key_len"] = 1 << 30 }},
+		{"migration", func(fields map[string]interface{}) {
+			fields["version"] = "0.3"
+			fields["entries"] = []interface{}{"x"}
+		}},
+	}
+	for _, d := range corruptData {
+		fields := make(map[string]interface{})
+		assert.NoError(t, json.Unmarshal(cnt, &fields), d.Name)
+		fields["entries"] = []interface{}{}
+		d.Modify(fields)
+		tmp, err := json.Marshal(fields)
+		assert.NoError(t, err, d.Name)
+		assert.NoError(t, ioutil.WriteFile(path+".tmp", tmp, 0600), d.Name)
+		assert.NoError(t, os.Chtimes(path+".tmp", now.Add(time.Minute), now.Add(time.Minute)), d.Name)
+		wallet.Reset()
+		report, err = wallet.LoadWallet()
+		assert.NoError(t, err, d.Name)
+		assert.Empty(t, report.Recovered, d.Name)
+		addrs, err = wallet.GetAddresses(wlt.GetID())
+		assert.NoError(t, err, d.Name)
+		assert.Len(t, addrs, 2, d.Name)
+		_, err = os.Stat(path + ".tmp")
+		assert.True(t, os.IsNotExist(err), d.Name)
+	}
+
+	// truncated file is recovered from the backup, and kept as .corrupt.
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`{"id": "`), 0600))
+	wallet.Reset()
+	report, err = wallet.LoadWallet()
+	assert.NoError(t, err)
+	assert.Equal(t, []wallet.Recovery{{Name: name, From: name + ".bak"}}, report.Recovered)
+	addrs, err = wallet.GetAddresses(wlt.GetID())
+	assert.NoError(t, err)
+	assert.Len(t, addrs, 1)
+	seed, err := wallet.GetSeed(wlt.GetID(), passwd)
+	assert.NoError(t, err)
+	assert.Equal(t, "seed1", seed)
+	corrupt, err := ioutil.ReadFile(path + ".corrupt")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"id": "`, string(corrupt))
+}
+
+func TestLoadReport(t *testing.T) {
+	wltDir, teardown, err := setup(t)
+	assert.Nil(t, err)
+	defer teardown()
+	wallet.Reset()
+
+	passwd := "12345678"
+	wlt, err := wallet.NewBrainWallet("spo", "l1", "seed1", passwd)
+	assert.NoError(t, err)
+
+	for name, cnt := range map[string]string{
+		"spo_broken.wlt":  `{"id": "spo_broken"`,
+		"btc_unknown.wlt": `{}`,
+		"noname.wlt":      `{}`,
+		"spo_readme.txt":  `not a wallet`,
+		"skycoin_bad.wlt": `{"version": "` + wallet.WalletVersion + `", "id": "skycoin_bad", "entries": "x"}`,
+		"spo_zz_copy.wlt": "",
+	} {
+		if cnt == "" {
+			d, err := ioutil.ReadFile(filepath.Join(wltDir, wlt.GetID()+"."+wallet.Ext))
+			assert.NoError(t, err)
+			cnt = string(d)
+		}
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(wltDir, name), []byte(cnt), 0600))
+	}
+
+	wallet.Reset()
+	report, err := wallet.LoadWallet()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{wlt.GetID()}, report.Loaded)
+	assert.Len(t, report.Corrupt, 2)
+	for _, f := range report.Corrupt {
+		assert.Contains(t, []string{"spo_broken.wlt", "skycoin_bad.wlt"}, f.Name)
+	}
+	assert.Equal(t, []wallet.LoadFailure{
+		{Name: "btc_unknown.wlt", Reason: "btc wallet not supported"},
+		{Name: "noname.wlt", Reason: "invalid wallet file name"},
+		{Name: "spo_zz_copy.wlt", Reason: wlt.GetID() + " already exist"},
+	}, report.Skipped)
+
+	// healthy wallet is still usable.
+	seed, err := wallet.GetSeed(wlt.GetID(), passwd)
+	assert.NoError(t, err)
+	assert.Equal(t, "seed1", seed)
+}
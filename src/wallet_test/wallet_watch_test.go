@@ -0,0 +1,75 @@
+package wallet_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/MDLlife/wallet-api/src/coin/spo"
+	"github.com/MDLlife/wallet-api/src/wallet"
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchWallet(t *testing.T) {
+	_, teardown, err := setup(t)
+	assert.Nil(t, err)
+	defer teardown()
+	wallet.Reset()
+
+	pub1, _ := cipher.GenerateDeterministicKeyPair([]byte("watch1"))
+	pub2, _ := cipher.GenerateDeterministicKeyPair([]byte("watch2"))
+	addr1 := cipher.AddressFromPubKey(pub1).String()
+	addr2 := cipher.AddressFromPubKey(pub2).String()
+
+	_, err = wallet.NewWatchWallet("btc", "w1", []string{addr1})
+	assert.EqualError(t, err, "btc wallet not regestered")
+	_, err = wallet.NewWatchWallet("spo", "w1", nil)
+	assert.EqualError(t, err, "no address to watch")
+	_, err = wallet.NewWatchWallet("spo", "w1", []string{"badaddr"})
+	assert.EqualError(t, err, "invalid address or pubkey badaddr")
+	_, err = wallet.NewWatchWallet("spo", "w1", []string{addr2, pub2.Hex()})
+	assert.EqualError(t, err, "duplicate address "+addr2)
+
+	wlt, err := wallet.NewWatchWallet("spo", "w1", []string{addr1, pub2.Hex()})
+	assert.NoError(t, err)
+	id := wlt.GetID()
+	assert.True(t, strings.HasPrefix(id, "watch_spo_"))
+	assert.Equal(t, "spo", wlt.GetType())
+	addrs, err := wallet.GetAddresses(id)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{addr1, addr2}, addrs)
+	ok, err := wallet.IsContain(id, []string{addr2})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// signing needs secrets.
+	errWatchOnly := "watch-only wallet has no secrets, signing is not supported"
+	assert.EqualError(t, wallet.VerifyPassword(id, ""), errWatchOnly)
+	_, err = wallet.NewAddresses(id, 1, "12345678")
+	assert.EqualError(t, err, errWatchOnly)
+	_, _, err = wallet.GetKeypair(id, addr1, "12345678")
+	assert.EqualError(t, err, errWatchOnly)
+	_, err = wallet.GetSeckey(id, addr1, "12345678")
+	assert.EqualError(t, err, errWatchOnly)
+	_, err = wallet.GetSeed(id, "12345678")
+	assert.EqualError(t, err, errWatchOnly)
+	assert.EqualError(t, wallet.Unlock(id, "12345678", time.Minute), errWatchOnly)
+
+	// changing all passwords skips the watch-only wallet.
+	_, err = wallet.NewBrainWallet("spo", "l1", "seed1", "12345678")
+	assert.NoError(t, err)
+	assert.NoError(t, wallet.ChangeAllPasswords("12345678", "87654321"))
+
+	wallet.Reset()
+	report, err := wallet.LoadWallet()
+	assert.NoError(t, err)
+	assert.Contains(t, report.Loaded, id)
+	assert.Empty(t, report.Corrupt)
+	loaded, err := wallet.GetAddresses(id)
+	assert.NoError(t, err)
+	assert.Equal(t, addrs, loaded)
+
+	assert.NoError(t, wallet.Remove(id))
+	assert.False(t, wallet.IsExist(id))
+}